@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listFlag is a repeatable flag that also splits each value on commas, so
+// "-x a,b -x c" yields [a b c]. A flag given as "" adds one empty entry.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(v string) error {
+	if strings.TrimSpace(v) == "" {
+		*l = append(*l, "")
+		return nil
+	}
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			*l = append(*l, part)
+		}
+	}
+	return nil
+}
+
+// hostMapFlag is a repeatable old=new flag; later pairs for the same old host
+// replace earlier ones.
+type hostMapFlag map[string]string
+
+func (m *hostMapFlag) String() string {
+	var parts []string
+	for old, repl := range *m {
+		parts = append(parts, old+"="+repl)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func (m *hostMapFlag) Set(v string) error {
+	old, repl, ok := strings.Cut(v, "=")
+	old, repl = strings.TrimSpace(old), strings.TrimSpace(repl)
+	if !ok || old == "" || repl == "" {
+		return fmt.Errorf("want old=new, got %q", v)
+	}
+	if *m == nil {
+		*m = hostMapFlag{}
+	}
+	(*m)[strings.ToLower(old)] = repl
+	return nil
+}
+
+// ageFlag is a time.Duration flag that also accepts whole days and weeks,
+// e.g. "365d" or "2w", which time.ParseDuration doesn't.
+type ageFlag time.Duration
+
+func (a *ageFlag) String() string {
+	return time.Duration(*a).String()
+}
+
+func (a *ageFlag) Set(v string) error {
+	v = strings.TrimSpace(v)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, err := strconv.Atoi(strings.TrimSuffix(v, suffix)); err == nil && strings.HasSuffix(v, suffix) {
+			*a = ageFlag(time.Duration(n) * unit)
+			return nil
+		}
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("want a duration like 365d, 2w or 36h, got %q", v)
+	}
+	*a = ageFlag(d)
+	return nil
+}
+
+// emitFlag maps -emit target names to the path variables of the flags they
+// stand for, so "-emit markdown=cl.md,atom=feed.xml" is the same as
+// "-changelog cl.md -atom feed.xml". It is repeatable like listFlag.
+type emitFlag map[string]*string
+
+func (e emitFlag) String() string {
+	var parts []string
+	for name, p := range e {
+		if p != nil && *p != "" {
+			parts = append(parts, name+"="+*p)
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func (e emitFlag) Set(v string) error {
+	for _, part := range strings.Split(v, ",") {
+		name, path, ok := strings.Cut(strings.TrimSpace(part), "=")
+		name, path = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(path)
+		if !ok || path == "" {
+			return fmt.Errorf("want target=path, got %q", part)
+		}
+		p, known := e[name]
+		if !known {
+			return fmt.Errorf("unknown target %q (want %s)", name, strings.Join(e.names(), ", "))
+		}
+		*p = path
+	}
+	return nil
+}
+
+func (e emitFlag) names() []string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,393 @@
+// Command altstudio-fix normalizes an AltStore-style source into
+// output.json. See package riperepo for the normalization itself.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	riperepo "github.com/RipeStore/theriperepo"
+)
+
+// version is the build version, set with -ldflags "-X main.version=...".
+var version = "dev"
+
+func main() {
+	var opts riperepo.Options
+	var showVersion, selftest, onlyChanged, decimalSizes, relativeDates, failOnWarning, quiet, verbose, dryRun bool
+	var manifestPath, diffPath, splitDir, categoryDir, indexPath, htmlPath, changelogPath, atomPath, csvPath, bothBase, zipEntry string
+	outPath := "output.json"
+	flag.StringVar(&manifestPath, "manifest", "", "read the inputs from this file, one path or http(s) URL per line (# starts a comment), and merge them in order; replaces the input argument")
+	flag.BoolVar(&opts.NDJSON, "ndjson", false, "input is newline-delimited JSON, one source per line, merged in order")
+	flag.StringVar(&opts.Format, "format", "auto", "input format: auto, json or plist (auto detects a leading <?xml or <plist)")
+	flag.BoolVar(&opts.DetectDupes, "detect-dupes", false, "warn about duplicate keys within any JSON object (the last one still wins)")
+	flag.BoolVar(&opts.SortNews, "sort-news", false, "sort news newest first by date (undated items last)")
+	flag.BoolVar(&opts.ASCII, "ascii", false, "escape all non-ASCII characters in the output as \\uXXXX")
+	flag.BoolVar(&opts.SortKeys, "sort-keys", false, "sort the keys of all free-form objects (e.g. appPermissions); known fields keep their order")
+	flag.Var((*listFlag)(&opts.IncludeCategories), "include-category", "keep only apps in this category (repeatable or comma-separated)")
+	flag.Var((*listFlag)(&opts.ExcludeCategories), "exclude-category", "drop apps in this category (repeatable or comma-separated; \"\" means uncategorized)")
+	flag.Var((*listFlag)(&opts.IncludeBundleIDs), "include-bundleid", "keep only the app with this bundle identifier (repeatable or comma-separated)")
+	flag.Var((*listFlag)(&opts.ExcludeBundleIDs), "exclude-bundleid", "drop the app with this bundle identifier (repeatable or comma-separated)")
+	flag.IntVar(&opts.MaxScreenshots, "max-screenshots", 0, "keep at most this many screenshots per app (0 keeps all)")
+	flag.BoolVar(&opts.NormalizeVersion, "normalize-version", false, "strip a leading v/V from version strings like v1.2.3 or v1.2.0-beta.1; others such as v2-final are kept")
+	flag.BoolVar(&opts.EmitCount, "emit-count", false, "add a top-level appCount field (after news) with the number of apps")
+	flag.Var((*hostMapFlag)(&opts.RewriteHosts), "rewrite-host", "rewrite URLs on host old to host new, keeping the path (old=new, repeatable)")
+	flag.Var((*listFlag)(&opts.AllowDownloadPrefixes), "allow-download-prefix", "skip the .ipa/.tipa extension check for downloadURLs starting with this prefix, e.g. https://api.github.com/ (repeatable or comma-separated)")
+	flag.StringVar(&opts.DefaultIdentifier, "default-identifier", "", "identifier to use when the input has none (default "+riperepo.HardcodedIdentifier+")")
+	flag.StringVar(&opts.DefaultSourceURL, "default-source-url", "", "sourceURL to use when the input has none (default "+riperepo.HardcodedSourceURL+")")
+	flag.BoolVar(&opts.StampGenerator, "stamp-generator", false, "add a trailing generator object (tool name, build version, generation time); off by default to keep output deterministic")
+	flag.BoolVar(&opts.Lenient, "lenient", false, "accept // and /* */ comments and trailing commas in JSON input")
+	flag.BoolVar(&opts.StripControl, "strip-control", false, "remove control characters (except newline and tab) from all string fields")
+	flag.BoolVar(&opts.ASCIIPunct, "ascii-punct", false, "replace curly quotes, typographic dashes and ellipses in names and descriptions with ASCII")
+	flag.Var((*listFlag)(&opts.RequireFields), "require", "app fields that must be non-empty, by JSON name, e.g. iconURL,category (repeatable or comma-separated)")
+	flag.StringVar(&opts.RequireMode, "require-mode", "warn", "what to do with apps missing a -require field: drop or warn")
+	flag.Var((*ageFlag)(&opts.StaleAfter), "stale-after", "warn about apps whose latest version is older than this, e.g. 365d (days, weeks or a Go duration)")
+	flag.StringVar(&opts.CacheDir, "cache-dir", "", "remember IPA Info.plist values per downloadURL in this directory across runs (reachability and redirects are always checked afresh)")
+	flag.BoolVar(&opts.RefreshCache, "refresh-cache", false, "ignore what -cache-dir holds and fetch everything again (the cache is rewritten)")
+	flag.IntVar(&opts.MinIconSize, "min-icon-size", 0, "fetch each app icon's header and warn when it is smaller than this many pixels per side (0 disables)")
+	flag.BoolVar(&opts.InlineIcons, "inline-icons", false, "download every icon and replace its URL with a base64 data: URL, for offline sources")
+	flag.Int64Var(&opts.MaxInlineIconSize, "max-inline-icon-size", 256<<10, "largest icon in bytes that -inline-icons will embed; bigger ones keep their URL")
+	flag.BoolVar(&opts.NoDefaultIdentifier, "no-default-identifier", false, "leave the identifier as the input has it, even if empty")
+	flag.BoolVar(&opts.NoDefaultSourceURL, "no-default-source-url", false, "leave the sourceURL as the input has it, even if empty")
+	flag.BoolVar(&opts.DropBeta, "drop-beta", false, "remove apps and versions marked \"beta\": true (by default they are kept, beta field included)")
+	flag.BoolVar(&opts.VersionDescFallback, "version-desc-fallback", false, "give versions without a localizedDescription the app's description (or subtitle)")
+	flag.StringVar(&opts.InputEncoding, "input-encoding", "", "decode the input as utf-8, utf-16le, utf-16be, latin1 or windows-1252 instead of detecting a byte-order mark")
+	flag.BoolVar(&opts.CheckSharedScreenshots, "check-shared-screenshots", false, "warn when the same screenshot URL is used by more than one app (a likely copy-paste slip)")
+	flag.BoolVar(&opts.ClientOrder, "client-order", false, "order apps the way the client displays them: featured apps first (in featuredApps order), then the rest by category and name")
+	flag.BoolVar(&opts.ExplicitNotify, "explicit-notify", false, "always write each news item's notify field, so false is explicit rather than omitted")
+	flag.BoolVar(&opts.Strict, "strict", false, "reject JSON input containing fields the tool doesn't recognize (e.g. a misspelled downlaodURL), listing each by path")
+	flag.BoolVar(&opts.DedupScreenshotContent, "prune-duplicate-screenshots-global", false, "download every screenshot and rewrite URLs whose content is identical to an earlier one's to that first URL")
+	flag.BoolVar(&opts.EncodeURLs, "encode-urls", false, "percent-encode spaces and other unsafe characters in every URL, e.g. \"My App.ipa\" becomes \"My%20App.ipa\"; already-encoded URLs are unchanged")
+	flag.BoolVar(&opts.CanonicalPermissions, "canonical-permissions", false, "rewrite every appPermissions as {\"entitlements\": [sorted names], \"privacy\": {key: description}}, accepting the common variants; uninterpretable ones are kept with a warning")
+	flag.BoolVar(&opts.DropPatreonGated, "drop-patreon-gated", false, "remove apps and versions available only to patrons, for a public source: those with \"patreon\": true or a patreon object with a pledge above 0 or any tiers")
+	flag.IntVar(&opts.LimitApps, "limit-apps", 0, "keep only the first N apps after filtering and sorting, e.g. for small test fixtures (0 keeps all)")
+	flag.BoolVar(&opts.DropNoDownload, "drop-no-download", false, "remove versions without a downloadURL (they are always warned about)")
+	flag.BoolVar(&opts.PruneEmptyApps, "prune-empty-apps", false, "remove apps that have no versions, including those emptied by -drop-beta, -drop-patreon-gated, -min-minos/-max-minos or -drop-no-download")
+	flag.BoolVar(&opts.PreserveOrder, "preserve-order", false, "guarantee apps, versions, news and featured apps keep their input order: refuse -sort-news and -client-order (all normalization still applies)")
+	flag.StringVar(&opts.AssumeSizeUnit, "assume-size-unit", "", "unit of sizes given as plain numbers: bytes, kb or mb (binary); sizes with a unit are unaffected, implausible results are warned about (default bytes, unchecked)")
+	flag.BoolVar(&opts.ResolveGitHubReleases, "resolve-github-releases", false, "rewrite downloadURLs that are GitHub release API endpoints (api.github.com/repos/OWNER/REPO/releases/latest, /tags/TAG or a numeric /ID) to the release's .ipa asset, filling in its size")
+	flag.BoolVar(&opts.TitleCaseDevelopers, "title-case-developers", false, "title-case developer names written entirely in upper or lower case (JOHN DOE -> John Doe); mixed-case names are left alone")
+	flag.BoolVar(&opts.ResolveNewsAppIDs, "resolve-news-appids", false, "rewrite news appIDs that are a 0-based index into apps, or an app name or slug, to that app's bundleIdentifier")
+	flag.Var((*ageFlag)(&opts.PruneNewsOlderThan), "prune-news-older-than", "drop news items dated longer ago than this, e.g. 180d (days, weeks or a Go duration); undated and unparseable items are kept")
+	flag.BoolVar(&opts.ReportNulls, "report-nulls", false, "warn about each known field written as an explicit null (e.g. \"downloadURL\": null), which otherwise reads the same as a missing one")
+	flag.StringVar(&opts.MinMinOS, "min-minos", "", "drop versions whose minOSVersion is below this OS version, e.g. 14.0 (versions without a parseable minOSVersion are kept)")
+	flag.StringVar(&opts.MaxMinOS, "max-minos", "", "drop versions whose minOSVersion is above this OS version, e.g. 12.5 for a source for older devices (versions without a parseable minOSVersion are kept)")
+	flag.BoolVar(&opts.DedupFeatured, "dedup-featured", false, "drop repeated featuredApps entries, keeping the first of each in its place")
+	flag.BoolVar(&opts.CheckURLs, "check-urls", false, "check that every downloadURL is reachable and every iconURL serves an image")
+	flag.BoolVar(&opts.ResolveDownloads, "resolve-downloads", false, "follow redirects and rewrite each downloadURL to its final URL")
+	flag.BoolVar(&opts.VerifyIPA, "verify-ipa", false, "download each IPA and check its Info.plist bundle id and version, and its sha256 if declared")
+	flag.Int64Var(&opts.MaxIPASize, "max-ipa-size", 2<<30, "largest IPA in bytes that -verify-ipa will download")
+	flag.IntVar(&opts.Concurrency, "concurrency", 8, "max concurrent network requests")
+	flag.DurationVar(&opts.HTTPTimeout, "http-timeout", 30*time.Second, "timeout for connecting to a host and waiting for its response headers")
+	flag.StringVar(&opts.UserAgent, "user-agent", riperepo.DefaultUserAgent, "User-Agent header for all network requests")
+	flag.IntVar(&opts.Retries, "retries", 2, "retries with exponential backoff on timeouts, reset connections and 429/5xx responses")
+	flag.StringVar(&zipEntry, "entry", "", "with a .zip input, the archive entry holding the source (default: the first .json entry)")
+	flag.StringVar(&splitDir, "split-dir", "", "also write one <bundleIdentifier>.json per app into this directory")
+	flag.StringVar(&categoryDir, "category-dir", "", "also write one <category>.json per category, holding that category's apps, plus an index.json of categories, into this directory")
+	flag.StringVar(&indexPath, "index", "", "also write a minimal app index (name, id, icon, category, latest version) to this path")
+	flag.StringVar(&htmlPath, "html", "", "also write a static HTML page listing the apps by category to this path")
+	flag.StringVar(&bothBase, "both", "", "write base.json (indented) and base.min.json (compact) instead of output.json")
+	flag.StringVar(&changelogPath, "changelog", "", "also write a Markdown changelog of the news and every app's versions to this path")
+	flag.BoolVar(&relativeDates, "relative-dates", false, "show version and news dates in the -changelog relative to today, e.g. \"3 days ago\", instead of YYYY-MM-DD")
+	flag.StringVar(&csvPath, "csv", "", "also write a CSV of apps (id, developer, category, latest version, date and size, screenshot count) to this path")
+	flag.BoolVar(&decimalSizes, "decimal-sizes", false, "show human-readable sizes in decimal units (1 KB = 1000 bytes) instead of binary")
+	flag.StringVar(&atomPath, "atom", "", "also write the news as an Atom 1.0 feed to this path")
+	flag.Var(emitFlag{
+		"json":         &outPath,
+		"index":        &indexPath,
+		"html":         &htmlPath,
+		"markdown":     &changelogPath,
+		"csv":          &csvPath,
+		"atom":         &atomPath,
+		"split-dir":    &splitDir,
+		"category-dir": &categoryDir,
+	}, "emit", "write several outputs from one run, as target=path pairs (json, index, html, markdown, csv, atom, split-dir, category-dir), e.g. json=out.json,markdown=CHANGES.md (repeatable or comma-separated)")
+	flag.StringVar(&diffPath, "diff", "", "print a semantic diff of the normalized input against this existing source")
+	flag.BoolVar(&onlyChanged, "only-changed", false, "don't rewrite an output file whose contents would be identical")
+	flag.BoolVar(&dryRun, "dry-run", false, "don't write output.json; with -diff, exit 7 if there are changes")
+	flag.BoolVar(&showVersion, "version", false, "print the build version and exit")
+	flag.BoolVar(&selftest, "selftest", false, "normalize the input, normalize the result again and exit 8 if the two differ; writes nothing")
+	flag.BoolVar(&failOnWarning, "fail-on-warning", false, "exit non-zero if any warning was reported")
+	flag.BoolVar(&quiet, "quiet", false, "only print errors (and warnings when -fail-on-warning is set)")
+	flag.BoolVar(&verbose, "verbose", false, "log per-app and per-version normalization decisions")
+	flag.Usage = func() {
+		fmt.Println("Usage: go run fixrepo.go [flags] input.json")
+		fmt.Println("The input may also be a .zip archive holding the source JSON (see -entry).")
+		fmt.Println("Several inputs can be listed in a file and merged with -manifest instead.")
+		flag.PrintDefaults()
+		fmt.Println()
+		fmt.Println("Environment:")
+		fmt.Println("  RIPEREPO_IDENTIFIER   default identifier when -default-identifier isn't given")
+		fmt.Println("  RIPEREPO_SOURCE_URL   default sourceURL when -default-source-url isn't given")
+		fmt.Println("  GITHUB_TOKEN          token for -resolve-github-releases API requests (raises the rate limit)")
+		fmt.Println("A value in the input wins over the flag, the flag over the environment,")
+		fmt.Println("and the environment over the compiled-in default.")
+	}
+	flag.Parse()
+	envDefaults(&opts)
+	opts.GitHubToken = os.Getenv("GITHUB_TOKEN")
+	opts.ToolVersion = version
+
+	if showVersion {
+		fmt.Println(riperepo.ToolName, version)
+		return
+	}
+
+	switch {
+	case quiet:
+		riperepo.SetLogLevel(riperepo.LevelQuiet)
+	case verbose:
+		riperepo.SetLogLevel(riperepo.LevelDebug)
+	default:
+		riperepo.SetLogLevel(riperepo.LevelInfo)
+	}
+
+	if err := opts.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var refs []string
+	switch {
+	case manifestPath != "" && flag.NArg() > 0:
+		fmt.Fprintln(os.Stderr, "give either an input file or -manifest, not both")
+		os.Exit(1)
+	case manifestPath != "":
+		var err error
+		if refs, err = readManifest(manifestPath); err != nil {
+			fmt.Fprintln(os.Stderr, "read error:", err)
+			os.Exit(2)
+		}
+	case flag.NArg() > 0:
+		refs = []string{flag.Arg(0)}
+	default:
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	// SIGINT cancels in-flight network requests; the run then stops without
+	// writing a partially checked output
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	srcs := make([][]byte, 0, len(refs))
+	for _, ref := range refs {
+		b, err := readSource(ctx, ref, zipEntry, opts)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "read error:", err)
+			os.Exit(2)
+		}
+		srcs = append(srcs, b)
+	}
+
+	if selftest {
+		if len(srcs) > 1 {
+			fmt.Fprintln(os.Stderr, "selftest: takes a single input, not a -manifest of several")
+			os.Exit(1)
+		}
+		diffs, err := riperepo.SelfTest(ctx, srcs[0], opts)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "selftest:", err)
+			os.Exit(3)
+		}
+		if len(diffs) > 0 {
+			fmt.Fprintln(os.Stderr, "selftest: output changes when normalized again")
+			for _, d := range diffs {
+				fmt.Fprintln(os.Stderr, d)
+			}
+			os.Exit(8)
+		}
+		if !quiet {
+			fmt.Fprintln(os.Stderr, "selftest: ok, normalization is idempotent")
+		}
+		return
+	}
+
+	out, rep, err := riperepo.BuildSources(ctx, srcs, opts)
+	if ctx.Err() != nil {
+		if !quiet && rep != nil {
+			printSummary(os.Stderr, rep, decimalSizes)
+		}
+		fmt.Fprintln(os.Stderr, "interrupted")
+		os.Exit(130)
+	}
+	if err != nil {
+		var optErr *riperepo.OptionError
+		if errors.As(err, &optErr) {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr, "json parse:", err)
+		os.Exit(3)
+	}
+
+	outBytes, err := riperepo.Encode(out, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "marshal:", err)
+		os.Exit(4)
+	}
+
+	var changes []string
+	if diffPath != "" {
+		existing, err := ioutil.ReadFile(diffPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "read error:", err)
+			os.Exit(2)
+		}
+		// normalize the existing file too, so only real changes show up
+		older, err := riperepo.Decode(existing, opts)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "json parse:", diffPath+":", err)
+			os.Exit(3)
+		}
+		changes = riperepo.DiffRoots(older, out)
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+		if len(changes) == 0 && !quiet {
+			fmt.Println("no changes")
+		}
+	}
+
+	// write to output.json (or the -emit json path), or base.json and
+	// base.min.json with -both
+	if !dryRun {
+		files := []outputFile{{outPath, outBytes}}
+		if bothBase != "" {
+			var min bytes.Buffer
+			if err := json.Compact(&min, outBytes); err != nil {
+				fmt.Fprintln(os.Stderr, "marshal:", err)
+				os.Exit(4)
+			}
+			files = []outputFile{{bothBase + ".json", outBytes}, {bothBase + ".min.json", min.Bytes()}}
+		}
+		var wrote []string
+		for _, f := range files {
+			ok, err := writeOutput(f, onlyChanged)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "write:", err)
+				os.Exit(5)
+			}
+			if ok {
+				wrote = append(wrote, f.name)
+			}
+		}
+		if !quiet {
+			if len(wrote) == 0 {
+				fmt.Println("no changes")
+			} else {
+				fmt.Printf("Wrote %s (ordered, normalized).\n", strings.Join(wrote, " and "))
+			}
+		}
+	}
+	// the other outputs, all from the same normalized out
+	extras := []struct {
+		path  string
+		write func(path string) error
+	}{
+		{indexPath, func(p string) error { return riperepo.WriteIndex(p, out.Apps) }},
+		{htmlPath, func(p string) error { return riperepo.WriteHTML(p, out) }},
+		{changelogPath, func(p string) error { return riperepo.WriteChangelog(p, out, relativeDates) }},
+		{csvPath, func(p string) error { return riperepo.WriteCSV(p, out.Apps, decimalSizes) }},
+		{atomPath, func(p string) error { return riperepo.WriteAtom(p, out) }},
+		{splitDir, func(p string) error { return riperepo.WriteSplitDir(p, out.Apps, rep) }},
+		{categoryDir, func(p string) error { return riperepo.WriteCategoryDir(p, out.Apps, rep) }},
+	}
+	var artifacts []string
+	for _, x := range extras {
+		if x.path == "" || dryRun {
+			continue
+		}
+		if err := x.write(x.path); err != nil {
+			fmt.Fprintln(os.Stderr, "write:", err)
+			os.Exit(5)
+		}
+		artifacts = append(artifacts, x.path)
+	}
+
+	// with -fail-on-warning the warnings explain the exit code, so they are
+	// printed even when quiet
+	if !quiet || failOnWarning {
+		for _, w := range rep.Warnings {
+			fmt.Fprintln(os.Stderr, "warning:", w)
+		}
+	}
+	if !quiet {
+		printSummary(os.Stderr, rep, decimalSizes)
+		if len(artifacts) > 0 {
+			fmt.Fprintf(os.Stderr, "summary: also wrote %s\n", strings.Join(artifacts, ", "))
+		}
+	}
+	if failOnWarning && len(rep.Warnings) > 0 {
+		os.Exit(6)
+	}
+	// drift detection for CI: a dry run that would change the file fails
+	if dryRun && len(changes) > 0 {
+		os.Exit(7)
+	}
+}
+
+// envDefaults fills the default identifier and sourceURL from
+// RIPEREPO_IDENTIFIER and RIPEREPO_SOURCE_URL where no flag set them:
+// flag > env > compiled-in, and the library handles the last step.
+func envDefaults(opts *riperepo.Options) {
+	if opts.DefaultIdentifier == "" {
+		opts.DefaultIdentifier = os.Getenv("RIPEREPO_IDENTIFIER")
+	}
+	if opts.DefaultSourceURL == "" {
+		opts.DefaultSourceURL = os.Getenv("RIPEREPO_SOURCE_URL")
+	}
+}
+
+// outputFile is one file the run writes.
+type outputFile struct {
+	name string
+	data []byte
+}
+
+// writeOutput writes f. With onlyChanged it leaves a file that already holds
+// exactly f.data untouched, so its mtime doesn't churn, and reports false.
+func writeOutput(f outputFile, onlyChanged bool) (bool, error) {
+	if onlyChanged {
+		if old, err := ioutil.ReadFile(f.name); err == nil && bytes.Equal(old, f.data) {
+			return false, nil
+		}
+	}
+	return true, ioutil.WriteFile(f.name, f.data, 0644)
+}
+
+// printSummary writes a one-line account of what normalization did.
+func printSummary(w io.Writer, rep *riperepo.Report, decimalSizes bool) {
+	fmt.Fprintf(w, "summary: %d apps, %d versions, %d news; %d dates reformatted, %d unparseable; %d screenshot objects converted; %d dropped fields; %d warnings\n",
+		rep.Apps, rep.Versions, rep.News,
+		rep.DatesReformatted, rep.DatesUnparsed,
+		rep.ScreenshotObjects, rep.DroppedFields, len(rep.Warnings))
+	if rep.TotalSize > 0 {
+		fmt.Fprintf(w, "summary: %s of IPAs listed\n", riperepo.HumanizeSize(rep.TotalSize, decimalSizes))
+	}
+	if rep.DuplicateDownloads > 0 {
+		fmt.Fprintf(w, "summary: %d downloadURLs shared between versions\n", rep.DuplicateDownloads)
+	}
+	if rep.StaleApps > 0 {
+		fmt.Fprintf(w, "summary: %d stale apps\n", rep.StaleApps)
+	}
+	if rep.IncompleteApps > 0 {
+		fmt.Fprintf(w, "summary: %d apps missing required fields\n", rep.IncompleteApps)
+	}
+	if rep.NoScreenshotApps > 0 {
+		fmt.Fprintf(w, "summary: %d apps without screenshots\n", rep.NoScreenshotApps)
+	}
+	if rep.Cancelled > 0 {
+		fmt.Fprintf(w, "summary: %d network operations cancelled\n", rep.Cancelled)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	riperepo "github.com/RipeStore/theriperepo"
+)
+
+// unsetenv removes key for the rest of the test and restores it afterwards.
+func unsetenv(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "") // registers the restore
+	os.Unsetenv(key)
+}
+
+func TestEnvDefaults(t *testing.T) {
+	tests := []struct {
+		name            string
+		env             map[string]string // variables not listed are unset
+		flagID, flagURL string
+		wantID, wantURL string
+	}{
+		{
+			name:   "unset leaves the compiled-in default",
+			env:    map[string]string{},
+			wantID: riperepo.HardcodedIdentifier, wantURL: riperepo.HardcodedSourceURL,
+		},
+		{
+			name:   "env over compiled-in",
+			env:    map[string]string{"RIPEREPO_IDENTIFIER": "com.env.repo", "RIPEREPO_SOURCE_URL": "https://env.example/repo.json"},
+			wantID: "com.env.repo", wantURL: "https://env.example/repo.json",
+		},
+		{
+			name:   "flag over env",
+			env:    map[string]string{"RIPEREPO_IDENTIFIER": "com.env.repo", "RIPEREPO_SOURCE_URL": "https://env.example/repo.json"},
+			flagID: "com.flag.repo", flagURL: "https://flag.example/repo.json",
+			wantID: "com.flag.repo", wantURL: "https://flag.example/repo.json",
+		},
+		{
+			name:   "one set, one unset",
+			env:    map[string]string{"RIPEREPO_SOURCE_URL": "https://env.example/repo.json"},
+			wantID: riperepo.HardcodedIdentifier, wantURL: "https://env.example/repo.json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"RIPEREPO_IDENTIFIER", "RIPEREPO_SOURCE_URL"} {
+				if v, ok := tt.env[key]; ok {
+					t.Setenv(key, v)
+				} else {
+					unsetenv(t, key)
+				}
+			}
+			opts := riperepo.Options{DefaultIdentifier: tt.flagID, DefaultSourceURL: tt.flagURL}
+			envDefaults(&opts)
+			out, _, err := riperepo.Build(context.Background(), []byte(`{"name": "x"}`), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.Identifier != tt.wantID || out.SourceURL != tt.wantURL {
+				t.Errorf("identifier, sourceURL = %q, %q; want %q, %q", out.Identifier, out.SourceURL, tt.wantID, tt.wantURL)
+			}
+		})
+	}
+
+	t.Run("input wins over all", func(t *testing.T) {
+		t.Setenv("RIPEREPO_IDENTIFIER", "com.env.repo")
+		opts := riperepo.Options{}
+		envDefaults(&opts)
+		out, _, err := riperepo.Build(context.Background(), []byte(`{"identifier": "com.input.repo"}`), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Identifier != "com.input.repo" {
+			t.Errorf("identifier = %q, want the input's", out.Identifier)
+		}
+	})
+}
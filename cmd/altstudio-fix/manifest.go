@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	riperepo "github.com/RipeStore/theriperepo"
+)
+
+// readManifest returns the sources listed in the manifest at path, one per
+// line. Blank lines and lines starting with # are skipped. Relative paths
+// are taken relative to the manifest's directory; http(s) URLs are kept.
+func readManifest(path string) ([]string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !isURL(line) && !filepath.IsAbs(line) {
+			line = filepath.Join(filepath.Dir(path), line)
+		}
+		refs = append(refs, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("%s lists no sources", path)
+	}
+	return refs, nil
+}
+
+// readSource reads one input: an http(s) URL, or a file, unpacking a .zip
+// archive's entry (see readZipEntry).
+func readSource(ctx context.Context, ref, zipEntry string, opts riperepo.Options) ([]byte, error) {
+	if isURL(ref) {
+		return riperepo.FetchSource(ctx, ref, opts)
+	}
+	b, err := ioutil.ReadFile(ref)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(ref), ".zip") {
+		if b, err = readZipEntry(b, zipEntry); err != nil {
+			return nil, fmt.Errorf("%s: %v", ref, err)
+		}
+	}
+	return b, nil
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+// readZipEntry returns the contents of the source JSON inside a zip archive:
+// the entry named name, or the first .json entry when name is empty.
+func readZipEntry(b []byte, name string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+	var entry *zip.File
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if name != "" && f.Name == name || name == "" && strings.EqualFold(path.Ext(f.Name), ".json") {
+			entry = f
+			break
+		}
+	}
+	switch {
+	case entry == nil && name != "":
+		return nil, fmt.Errorf("no entry %q in archive", name)
+	case entry == nil:
+		return nil, fmt.Errorf("no .json entry in archive; pick one with -entry")
+	case !strings.EqualFold(path.Ext(entry.Name), ".json"):
+		return nil, fmt.Errorf("entry %q is not a .json file", entry.Name)
+	}
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
@@ -0,0 +1,166 @@
+package riperepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DiffRoots describes how newer differs from older, one change per line.
+// Apps are matched by bundleIdentifier, versions by version string and news
+// by identifier, so reordering alone is not a change. The generator stamp
+// changes every run and is ignored.
+func DiffRoots(older, newer Root) []string {
+	var lines []string
+	lines = append(lines, diffFields("", older, newer, "Apps", "News", "FeaturedApps", "Generator")...)
+	lines = append(lines, diffSets("featuredApps", older.FeaturedApps, newer.FeaturedApps)...)
+
+	oldApps := make(map[string]App, len(older.Apps))
+	for _, a := range older.Apps {
+		oldApps[a.BundleIdentifier] = a
+	}
+	newApps := make(map[string]App, len(newer.Apps))
+	for _, a := range newer.Apps {
+		newApps[a.BundleIdentifier] = a
+	}
+	for _, id := range sortedKeys(oldApps, newApps) {
+		o, inOld := oldApps[id]
+		n, inNew := newApps[id]
+		switch {
+		case !inOld:
+			lines = append(lines, fmt.Sprintf("+ app %s (%s)", id, n.Name))
+		case !inNew:
+			lines = append(lines, fmt.Sprintf("- app %s (%s)", id, o.Name))
+		default:
+			lines = append(lines, diffFields("app "+id+" ", o, n, "Versions")...)
+			lines = append(lines, diffVersions(id, o.Versions, n.Versions)...)
+		}
+	}
+
+	oldNews := make(map[string]NewsItem, len(older.News))
+	for _, ni := range older.News {
+		oldNews[ni.Identifier] = ni
+	}
+	newNews := make(map[string]NewsItem, len(newer.News))
+	for _, ni := range newer.News {
+		newNews[ni.Identifier] = ni
+	}
+	for _, id := range sortedKeys(oldNews, newNews) {
+		o, inOld := oldNews[id]
+		n, inNew := newNews[id]
+		switch {
+		case !inOld:
+			lines = append(lines, fmt.Sprintf("+ news %s (%s)", id, n.Title))
+		case !inNew:
+			lines = append(lines, fmt.Sprintf("- news %s (%s)", id, o.Title))
+		default:
+			lines = append(lines, diffFields("news "+id+" ", o, n)...)
+		}
+	}
+	return lines
+}
+
+func diffVersions(appID string, older, newer []Version) []string {
+	oldV := make(map[string]Version, len(older))
+	for _, v := range older {
+		oldV[v.Version] = v
+	}
+	newV := make(map[string]Version, len(newer))
+	for _, v := range newer {
+		newV[v.Version] = v
+	}
+	var lines []string
+	for _, ver := range sortedKeys(oldV, newV) {
+		o, inOld := oldV[ver]
+		n, inNew := newV[ver]
+		switch {
+		case !inOld:
+			lines = append(lines, fmt.Sprintf("+ app %s version %s", appID, ver))
+		case !inNew:
+			lines = append(lines, fmt.Sprintf("- app %s version %s", appID, ver))
+		default:
+			lines = append(lines, diffFields(fmt.Sprintf("app %s version %s ", appID, ver), o, n)...)
+		}
+	}
+	return lines
+}
+
+// diffFields compares two structs of the same type field by field, naming
+// fields by their JSON key. Fields listed in skip are left to the caller.
+func diffFields(prefix string, a, b interface{}, skip ...string) []string {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	t := va.Type()
+	var lines []string
+fields:
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			// bookkeeping that is never written out
+			continue
+		}
+		for _, s := range skip {
+			if f.Name == s {
+				continue fields
+			}
+		}
+		fa, fb := va.Field(i).Interface(), vb.Field(i).Interface()
+		if reflect.DeepEqual(fa, fb) {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		lines = append(lines, fmt.Sprintf("~ %s%s: %s -> %s", prefix, name, diffValue(fa), diffValue(fb)))
+	}
+	return lines
+}
+
+// diffValue renders v compactly for a diff line.
+func diffValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	s := string(b)
+	if len(s) > 80 {
+		s = s[:77] + "..."
+	}
+	return s
+}
+
+// diffSets reports entries added to or removed from an order-independent list.
+func diffSets(name string, older, newer []string) []string {
+	inOld := make(map[string]bool, len(older))
+	for _, s := range older {
+		inOld[s] = true
+	}
+	inNew := make(map[string]bool, len(newer))
+	for _, s := range newer {
+		inNew[s] = true
+	}
+	var lines []string
+	for _, s := range sortedKeys(inOld, inNew) {
+		switch {
+		case !inOld[s]:
+			lines = append(lines, fmt.Sprintf("+ %s %s", name, s))
+		case !inNew[s]:
+			lines = append(lines, fmt.Sprintf("- %s %s", name, s))
+		}
+	}
+	return lines
+}
+
+// sortedKeys returns the union of the keys of a and b, sorted.
+func sortedKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,97 @@
+package riperepo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// dupFrame tracks one open object or array while walking the token stream.
+type dupFrame struct {
+	obj       bool
+	path      string
+	keys      map[string]bool
+	key       string // current key, objects only
+	expectKey bool   // objects alternate key, value
+	idx       int    // current element, arrays only
+}
+
+// childPath is the path of the value about to be read inside f.
+func (f *dupFrame) childPath() string {
+	if f.obj {
+		return f.path + "." + f.key
+	}
+	return fmt.Sprintf("%s[%d]", f.path, f.idx)
+}
+
+// valueDone advances f past a completed member value.
+func (f *dupFrame) valueDone() {
+	if f.obj {
+		f.expectKey = true
+	} else {
+		f.idx++
+	}
+}
+
+// findDuplicateKeys walks b as a JSON token stream and describes every key
+// that appears more than once in the same object, with its path and line.
+// encoding/json silently keeps the last value, so this is report-only.
+func findDuplicateKeys(b []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	var stack []*dupFrame
+	var found []string
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if len(stack) == 0 && err == io.EOF {
+				return found, nil
+			}
+			return found, err
+		}
+		var top *dupFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		if top != nil && top.obj && top.expectKey {
+			if d, ok := tok.(json.Delim); ok && d == '}' {
+				stack = stack[:len(stack)-1]
+				if len(stack) > 0 {
+					stack[len(stack)-1].valueDone()
+				}
+				continue
+			}
+			key := tok.(string)
+			if top.keys[key] {
+				off := dec.InputOffset()
+				found = append(found, fmt.Sprintf("duplicate key %q at %s.%s (line %d)",
+					key, top.path, key, bytes.Count(b[:off], []byte("\n"))+1))
+			}
+			top.keys[key] = true
+			top.key = key
+			top.expectKey = false
+			continue
+		}
+
+		path := "$"
+		if top != nil {
+			path = top.childPath()
+		}
+		switch tok {
+		case json.Delim('{'):
+			stack = append(stack, &dupFrame{obj: true, path: path, keys: map[string]bool{}, expectKey: true})
+		case json.Delim('['):
+			stack = append(stack, &dupFrame{path: path})
+		case json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 {
+				stack[len(stack)-1].valueDone()
+			}
+		default:
+			if top != nil {
+				top.valueDone()
+			}
+		}
+	}
+}
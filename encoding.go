@@ -0,0 +1,60 @@
+package riperepo
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// decodeBOM transcodes UTF-16 input with a byte-order mark to UTF-8 and drops
+// a UTF-8 BOM, which encoding/json would reject. Input without a BOM is
+// returned as-is; invalid UTF-8 is repaired later by decodeSource.
+func decodeBOM(b []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(b, []byte{0xEF, 0xBB, 0xBF}):
+		return b[3:], nil
+	case bytes.HasPrefix(b, []byte{0xFF, 0xFE}):
+		return unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM).NewDecoder().Bytes(b)
+	case bytes.HasPrefix(b, []byte{0xFE, 0xFF}):
+		return unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder().Bytes(b)
+	}
+	return b, nil
+}
+
+// inputEncodings are the names Options.InputEncoding accepts. The UTF-16
+// decoders still honor a byte-order mark if there is one.
+var inputEncodings = map[string]encoding.Encoding{
+	"utf-8":        unicode.UTF8BOM,
+	"utf-16le":     unicode.UTF16(unicode.LittleEndian, unicode.UseBOM),
+	"utf-16be":     unicode.UTF16(unicode.BigEndian, unicode.UseBOM),
+	"latin1":       charmap.ISO8859_1,
+	"iso-8859-1":   charmap.ISO8859_1,
+	"windows-1252": charmap.Windows1252,
+}
+
+// decodeInput transcodes b from the named encoding to UTF-8. An empty name
+// means autodetection by byte-order mark, see decodeBOM.
+func decodeInput(b []byte, name string) ([]byte, error) {
+	if name == "" {
+		return decodeBOM(b)
+	}
+	enc, err := lookupInputEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	return enc.NewDecoder().Bytes(b)
+}
+
+// lookupInputEncoding returns the -input-encoding called name; case and
+// underscores for dashes don't matter.
+func lookupInputEncoding(name string) (encoding.Encoding, error) {
+	enc, ok := inputEncodings[strings.ToLower(strings.Replace(name, "_", "-", -1))]
+	if !ok {
+		return nil, fmt.Errorf("unknown -input-encoding %q (want utf-8, utf-16le, utf-16be, latin1 or windows-1252)", name)
+	}
+	return enc, nil
+}
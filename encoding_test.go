@@ -0,0 +1,81 @@
+package riperepo
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeUTF16BOM(t *testing.T) {
+	for _, name := range []string{"utf16le-bom.json", "utf16be-bom.json"} {
+		t.Run(name, func(t *testing.T) {
+			b, err := ioutil.ReadFile(filepath.Join("testdata", "encoding", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := decodeRaw(b, Options{}, &Report{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.Name != "Café Repo" {
+				t.Errorf("name = %q, want %q", out.Name, "Café Repo")
+			}
+			if len(out.Apps) != 1 || out.Apps[0].Name != "Ünïcode ✓" {
+				t.Errorf("apps = %+v, want one named %q", out.Apps, "Ünïcode ✓")
+			}
+		})
+	}
+}
+
+func TestDecodeBOMPassthrough(t *testing.T) {
+	plain := []byte(`{"name": "x"}`)
+	got, err := decodeBOM(append([]byte{0xEF, 0xBB, 0xBF}, plain...))
+	if err != nil || string(got) != string(plain) {
+		t.Errorf("UTF-8 BOM: got %q, %v; want %q", got, err, plain)
+	}
+	got, err = decodeBOM(plain)
+	if err != nil || string(got) != string(plain) {
+		t.Errorf("no BOM: got %q, %v; want it unchanged", got, err)
+	}
+}
+
+func TestInputEncodingLatin1(t *testing.T) {
+	// "Café Crème" in ISO-8859-1, with no BOM: é is 0xE9, è is 0xE8
+	src := []byte("{\"name\": \"Caf\xe9 Cr\xe8me\", \"apps\": [{\"name\": \"\xc9t\xe9\", \"bundleIdentifier\": \"com.a\"}]}")
+
+	for _, name := range []string{"latin1", "ISO-8859-1", "iso_8859_1"} {
+		out, err := decodeRaw(src, Options{InputEncoding: name}, &Report{})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if out.Name != "Café Crème" || out.Apps[0].Name != "Été" {
+			t.Errorf("%s: names = %q, %q; want Café Crème, Été", name, out.Name, out.Apps[0].Name)
+		}
+	}
+
+	// without the option the bytes aren't valid UTF-8 and get replaced
+	out, err := decodeRaw(src, Options{}, &Report{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Name == "Café Crème" {
+		t.Error("Latin-1 decoded without -input-encoding; the test input isn't Latin-1")
+	}
+
+	if _, err := decodeRaw(src, Options{InputEncoding: "ebcdic"}, &Report{}); err == nil {
+		t.Error("unknown encoding accepted")
+	}
+}
+
+func TestSelfTestInputEncoding(t *testing.T) {
+	src := []byte("{\"name\": \"Caf\xe9\", \"apps\": [{\"name\": \"A\", \"bundleIdentifier\": \"com.a\"}]}")
+	diffs, err := SelfTest(context.Background(), src, Options{InputEncoding: "latin1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) > 0 {
+		t.Errorf("selftest found drift:\n%s", strings.Join(diffs, "\n"))
+	}
+}
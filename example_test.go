@@ -0,0 +1,49 @@
+package riperepo_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	riperepo "github.com/RipeStore/theriperepo"
+)
+
+// developerRenamer maps old developer names to current ones.
+type developerRenamer map[string]string
+
+func (r developerRenamer) Transform(app riperepo.App) riperepo.App {
+	if name, ok := r[app.DeveloperName]; ok {
+		app.DeveloperName = name
+	}
+	return app
+}
+
+// This example renames a developer and gives apps without a tint color the
+// repo's own. Transformers run in order, after the built-in passes, so the
+// second one sees the renamed developer.
+func ExampleAppTransformer() {
+	src := `{"apps": [
+		{"name": "Alpha", "bundleIdentifier": "com.example.alpha", "developerName": "Old Studio"},
+		{"name": "Beta", "bundleIdentifier": "com.example.beta", "developerName": "Someone", "tintColor": "#112233"}
+	]}`
+	opts := riperepo.Options{AppTransformers: []riperepo.AppTransformer{
+		developerRenamer{"Old Studio": "New Studio"},
+		riperepo.AppTransformerFunc(func(app riperepo.App) riperepo.App {
+			if app.TintColor == "" && strings.HasSuffix(app.DeveloperName, "Studio") {
+				app.TintColor = "#ff8800"
+			}
+			return app
+		}),
+	}}
+	out, _, err := riperepo.Build(context.Background(), []byte(src), opts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, app := range out.Apps {
+		fmt.Println(app.Name, app.DeveloperName, app.TintColor)
+	}
+	// Output:
+	// Alpha New Studio #ff8800
+	// Beta Someone #112233
+}
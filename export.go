@@ -0,0 +1,259 @@
+package riperepo
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IndexEntry is one app in the -index listing: just enough for a client to
+// draw its app list before fetching details.
+type IndexEntry struct {
+	Name             string `json:"name,omitempty"`
+	BundleIdentifier string `json:"bundleIdentifier,omitempty"`
+	IconURL          string `json:"iconURL,omitempty"`
+	Category         string `json:"category,omitempty"`
+	Version          string `json:"version,omitempty"`
+	Size             int64  `json:"size,omitempty"`
+}
+
+// WriteIndex writes the -index listing for apps to path.
+func WriteIndex(path string, apps []App) error {
+	index := make([]IndexEntry, 0, len(apps))
+	for _, app := range apps {
+		e := IndexEntry{
+			Name:             app.Name,
+			BundleIdentifier: app.BundleIdentifier,
+			IconURL:          app.IconURL,
+			Category:         app.Category,
+		}
+		if v, ok := app.LatestVersion(); ok {
+			e.Version, e.Size = v.Version, v.Size
+		}
+		index = append(index, e)
+	}
+	b, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// WriteSplitDir writes each app to dir/<bundleIdentifier>.json. Apps without
+// a bundle identifier can't be named and are skipped with a warning.
+func WriteSplitDir(dir string, apps []App, rep *Report) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	written := make(map[string]string, len(apps))
+	for _, app := range apps {
+		if app.BundleIdentifier == "" {
+			rep.Warnf("split-dir: app %q has no bundleIdentifier, not written", app.Name)
+			continue
+		}
+		name := safeFilename(app.BundleIdentifier) + ".json"
+		if prev, ok := written[name]; ok {
+			rep.Warnf("split-dir: %s and %s both map to %s; keeping the first", prev, app.BundleIdentifier, name)
+			continue
+		}
+		written[name] = app.BundleIdentifier
+		b, err := json.MarshalIndent(app, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), b, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CategoryIndexEntry is one category in the index.json written by
+// WriteCategoryDir.
+type CategoryIndexEntry struct {
+	Category string `json:"category"`
+	File     string `json:"file"`
+	Apps     int    `json:"apps"`
+}
+
+// WriteCategoryDir writes the apps of each category, compared
+// case-insensitively, as a JSON array to dir/<category>.json, plus
+// dir/index.json listing the categories in alphabetical order. Apps without
+// a category go to uncategorized.json. Apps keep their order within a file.
+// Categories whose file name would clash with index.json or an earlier
+// category are skipped with a warning.
+func WriteCategoryDir(dir string, apps []App, rep *Report) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	byCategory := map[string][]App{}
+	for _, app := range apps {
+		c := normalizeCategory(app.Category)
+		byCategory[c] = append(byCategory[c], app)
+	}
+	categories := make([]string, 0, len(byCategory))
+	for c := range byCategory {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+
+	index := []CategoryIndexEntry{}
+	written := map[string]string{"index.json": "the index"}
+	for _, c := range categories {
+		name := safeFilename(defaultIfEmpty(c, "uncategorized")) + ".json"
+		if prev, ok := written[name]; ok {
+			rep.Warnf("category-dir: category %q maps to %s like %s; not written", c, name, prev)
+			continue
+		}
+		written[name] = fmt.Sprintf("category %q", c)
+		b, err := json.MarshalIndent(byCategory[c], "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), b, 0644); err != nil {
+			return err
+		}
+		index = append(index, CategoryIndexEntry{Category: c, File: name, Apps: len(byCategory[c])})
+	}
+	b, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "index.json"), b, 0644)
+}
+
+// safeFilename maps s to a name safe on any filesystem, keeping letters,
+// digits, '.', '-' and '_' and replacing everything else with '_'.
+func safeFilename(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+	// no hidden files or path tricks like "." and ".."
+	s = strings.TrimLeft(s, ".")
+	if s == "" {
+		s = "_"
+	}
+	return s
+}
+
+// WriteChangelog writes a Markdown changelog to path: the news items in
+// source order, then one section per app listing its versions newest first
+// with their date and release notes. With relativeDates, dates read like
+// "3 days ago" (see RelativeDate), as of now.
+func WriteChangelog(path string, out Root, relativeDates bool) error {
+	now := time.Now()
+	var buf bytes.Buffer
+	title := "Changelog"
+	if out.Name != "" {
+		title = out.Name + " changelog"
+	}
+	fmt.Fprintf(&buf, "# %s\n", title)
+	if len(out.News) > 0 {
+		buf.WriteString("\n## News\n")
+		for _, n := range out.News {
+			fmt.Fprintf(&buf, "\n### %s", defaultIfEmpty(n.Title, n.Identifier))
+			if d := displayDate(ParseFlexibleTime(n.Date), n.Date, relativeDates, now); d != "" {
+				fmt.Fprintf(&buf, " - %s", d)
+			}
+			buf.WriteString("\n")
+			if caption := strings.TrimSpace(n.Caption); caption != "" {
+				fmt.Fprintf(&buf, "\n%s\n", caption)
+			}
+			if n.URL != "" {
+				fmt.Fprintf(&buf, "\n<%s>\n", n.URL)
+			}
+		}
+	}
+	for _, app := range out.Apps {
+		if len(app.Versions) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "\n## %s", app.Name)
+		if app.BundleIdentifier != "" {
+			fmt.Fprintf(&buf, " (`%s`)", app.BundleIdentifier)
+		}
+		buf.WriteString("\n")
+		for _, v := range versionsNewestFirst(app.Versions) {
+			fmt.Fprintf(&buf, "\n### %s", v.Version)
+			if d := changelogDate(v, relativeDates, now); d != "" {
+				fmt.Fprintf(&buf, " - %s", d)
+			}
+			buf.WriteString("\n")
+			if desc := strings.TrimSpace(v.LocalizedDescription); desc != "" {
+				fmt.Fprintf(&buf, "\n%s\n", desc)
+			}
+		}
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// versionsNewestFirst returns a copy of versions sorted by date, newest
+// first; undated versions follow in their listed order.
+func versionsNewestFirst(versions []Version) []Version {
+	sorted := append([]Version(nil), versions...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ti, okI := sorted[i].ParsedDate()
+		tj, okJ := sorted[j].ParsedDate()
+		if !okI || !okJ {
+			return okI && !okJ
+		}
+		return ti.After(tj)
+	})
+	return sorted
+}
+
+// changelogDate shows v's date as YYYY-MM-DD, or relative to now if relative
+// is set, or the raw value if it never parsed.
+func changelogDate(v Version, relative bool, now time.Time) string {
+	t, _ := v.ParsedDate()
+	return displayDate(t, v.Date, relative, now)
+}
+
+// displayDate is changelogDate for a date already parsed into t, which is
+// zero if raw didn't parse.
+func displayDate(t time.Time, raw string, relative bool, now time.Time) string {
+	switch {
+	case t.IsZero():
+		return raw
+	case relative:
+		return RelativeDate(t, now)
+	}
+	return t.UTC().Format("2006-01-02")
+}
+
+// WriteCSV writes one row per app to path, with its latest version's number,
+// date and size (in bytes and human-readable, see HumanizeSize) and its
+// screenshot count.
+func WriteCSV(path string, apps []App, decimalSizes bool) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"name", "bundleIdentifier", "developerName", "category", "latestVersion", "latestDate", "latestSize", "latestSizeHuman", "screenshots"})
+	for _, app := range apps {
+		var version, date, size, human string
+		if v, ok := app.LatestVersion(); ok {
+			version, date = v.Version, v.Date
+			if v.Size > 0 {
+				size = strconv.FormatInt(v.Size, 10)
+				human = HumanizeSize(v.Size, decimalSizes)
+			}
+		}
+		w.Write([]string{app.Name, app.BundleIdentifier, app.DeveloperName, app.Category, version, date, size, human, strconv.Itoa(len(app.ScreenshotURLs))})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
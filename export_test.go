@@ -0,0 +1,111 @@
+package riperepo
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRelativeDate(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{now, "today"},
+		{time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), "today"},
+		{time.Date(2024, 3, 15, 23, 59, 0, 0, time.UTC), "today"}, // later today is still today
+		{time.Date(2024, 3, 14, 23, 59, 0, 0, time.UTC), "yesterday"},
+		{time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC), "tomorrow"},
+		{time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC), "3 days ago"},
+		{time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC), "in 5 days"},
+		{time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC), "1 week ago"},
+		{time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC), "3 weeks ago"},
+		{time.Date(2024, 2, 14, 0, 0, 0, 0, time.UTC), "1 month ago"},
+		{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "2 months ago"},
+		{time.Date(2023, 3, 15, 0, 0, 0, 0, time.UTC), "1 year ago"},
+		{time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC), "in 2 years"},
+		// calendar days are counted in UTC, whatever zone t is in
+		{time.Date(2024, 3, 15, 1, 0, 0, 0, time.FixedZone("UTC+5", 5*3600)), "yesterday"},
+	}
+	for _, tt := range tests {
+		if got := RelativeDate(tt.t, now); got != tt.want {
+			t.Errorf("RelativeDate(%v) = %q, want %q", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestWriteChangelogNews(t *testing.T) {
+	today := time.Now().UTC().Format(time.RFC3339)
+	out := Root{
+		Name: "Repo",
+		News: []NewsItem{
+			{Title: "Launch", Date: today, Caption: "We're live", URL: "https://example.com/launch"},
+			{Identifier: "undated-note", Caption: "No date here"},
+			{Title: "Odd", Date: "sometime"},
+		},
+		Apps: []App{{Name: "A", Versions: []Version{{Version: "1.0", Date: today}}}},
+	}
+	path := filepath.Join(t.TempDir(), "CHANGES.md")
+
+	if err := WriteChangelog(path, out, true); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"## News\n\n### Launch - today\n\nWe're live\n\n<https://example.com/launch>\n",
+		"### undated-note\n\nNo date here\n",
+		"### Odd - sometime\n",
+		"## A\n\n### 1.0 - today\n",
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("changelog lacks %q:\n%s", want, b)
+		}
+	}
+
+	if err := WriteChangelog(path, out, false); err != nil {
+		t.Fatal(err)
+	}
+	b, _ = ioutil.ReadFile(path)
+	if want := "### Launch - " + today[:10] + "\n"; !strings.Contains(string(b), want) {
+		t.Errorf("changelog lacks %q:\n%s", want, b)
+	}
+}
+
+func TestNewsFeedEntryIDs(t *testing.T) {
+	out := Root{Identifier: "com.example.repo", News: []NewsItem{
+		{Title: "Hello", Date: "2024-03-01"},
+		{Title: "Update", Date: "2024-03-02", URL: "https://example.com/u"},
+		{Identifier: "launch", Title: "Launch"},
+		{Identifier: "tag:example.com,2024:x", Title: "URI"},
+		{Title: "Hello", Date: "2024-03-01"}, // exact repeat of the first
+	}}
+	entries := newsFeedEntries(out)
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if seen[e.ID] {
+			t.Errorf("duplicate entry id %s", e.ID)
+		}
+		seen[e.ID] = true
+	}
+	if got := entries[2].ID; got != "urn:com.example.repo:launch" {
+		t.Errorf("identified item id = %s", got)
+	}
+	if got := entries[3].ID; got != "tag:example.com,2024:x" {
+		t.Errorf("URI item id = %s", got)
+	}
+	if !strings.HasPrefix(entries[0].ID, "urn:com.example.repo:news-") {
+		t.Errorf("unidentified item id = %s", entries[0].ID)
+	}
+
+	// the fallback id follows the item, not its position
+	out.News = []NewsItem{out.News[1], out.News[0]}
+	if again := newsFeedEntries(out); again[0].ID != entries[1].ID || again[1].ID != entries[0].ID {
+		t.Errorf("ids changed with order: %s, %s; want %s, %s", again[0].ID, again[1].ID, entries[1].ID, entries[0].ID)
+	}
+}
@@ -0,0 +1,131 @@
+package riperepo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// feedEntry is a news item reduced to what a syndication feed needs. Feed
+// writers build on newsFeedEntries so they agree on ids, dates and links.
+type feedEntry struct {
+	ID      string
+	Title   string
+	Summary string
+	Link    string
+	Updated time.Time // zero if the item's date didn't parse
+}
+
+// newsFeedEntries converts out.News to feed entries, in order. Identifiers
+// that aren't already URIs are scoped under the source identifier so they
+// stay unique across sources. Items without an identifier get one from a hash
+// of their title, date and URL, which survives reordering; exact repeats get
+// their position appended so no two entries share an id.
+func newsFeedEntries(out Root) []feedEntry {
+	entries := make([]feedEntry, 0, len(out.News))
+	seen := make(map[string]bool, len(out.News))
+	for i, n := range out.News {
+		id := n.Identifier
+		if id == "" {
+			sum := sha256.Sum256([]byte(n.Title + "\x00" + n.Date + "\x00" + n.URL))
+			id = "news-" + hex.EncodeToString(sum[:8])
+		}
+		if !strings.Contains(id, ":") {
+			id = "urn:" + out.Identifier + ":" + id
+		}
+		if seen[id] {
+			id += "-" + strconv.Itoa(i)
+		}
+		seen[id] = true
+		entries = append(entries, feedEntry{
+			ID:      id,
+			Title:   n.Title,
+			Summary: n.Caption,
+			Link:    n.URL,
+			Updated: ParseFlexibleTime(n.Date),
+		})
+	}
+	return entries
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	ID       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Updated string     `xml:"updated"`
+	Summary string     `xml:"summary,omitempty"`
+	Links   []atomLink `xml:"link"`
+}
+
+// WriteAtom writes out.News to path as an Atom 1.0 feed, using the source's
+// name, subtitle, sourceURL and website for the feed header. The feed's
+// updated time is that of its newest entry, so the file only changes when
+// the news does; entries without a parseable date use it too.
+func WriteAtom(path string, out Root) error {
+	entries := newsFeedEntries(out)
+	var newest time.Time
+	for _, e := range entries {
+		if e.Updated.After(newest) {
+			newest = e.Updated
+		}
+	}
+	if newest.IsZero() {
+		newest = time.Unix(0, 0)
+	}
+	feed := atomFeed{
+		Title:    defaultIfEmpty(out.Name, out.Identifier),
+		Subtitle: out.Subtitle,
+		ID:       defaultIfEmpty(out.SourceURL, "urn:"+out.Identifier),
+		Updated:  newest.UTC().Format(time.RFC3339),
+	}
+	if out.SourceURL != "" {
+		feed.Links = append(feed.Links, atomLink{Href: out.SourceURL, Rel: "related"})
+	}
+	if out.Website != "" {
+		feed.Links = append(feed.Links, atomLink{Href: out.Website, Rel: "alternate"})
+	}
+	for _, e := range entries {
+		updated := e.Updated
+		if updated.IsZero() {
+			updated = newest
+		}
+		entry := atomEntry{
+			ID:      e.ID,
+			Title:   e.Title,
+			Updated: updated.UTC().Format(time.RFC3339),
+			Summary: e.Summary,
+		}
+		if e.Link != "" {
+			entry.Links = []atomLink{{Href: e.Link, Rel: "alternate"}}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	b, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.Write(b)
+	buf.WriteString("\n")
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
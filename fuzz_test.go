@@ -0,0 +1,42 @@
+package riperepo
+
+import (
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func FuzzGetStr(f *testing.F) {
+	for _, seed := range []string{`"plain"`, `"café"`, `12345678901234567890`, `1.5e3`, `true`, `null`,
+		`{"nested": ["x", 1]}`, `["a", "\ud800"]`, "\"bad \xff\xfe bytes\""} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var v interface{}
+		if err := unmarshalNumbers(data, &v); err != nil {
+			// not JSON; try it as a raw string value instead
+			v = string(data)
+		}
+		if s := getStr(map[string]interface{}{"k": v}, "k"); !utf8.ValidString(s) {
+			t.Errorf("getStr(%q) = %q, not valid UTF-8", data, s)
+		}
+	})
+}
+
+func FuzzParseFlexibleTime(f *testing.F) {
+	for _, seed := range []string{"2024-03-01T10:00:00Z", "2024-03-01T10:00:00.123456789+05:30", "2024-03-01",
+		" 2024-03-01 10:00:00 ", "2024-03-01T10:00", "2024-02-30", "yesterday", ""} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		got := ParseFlexibleTime(s)
+		if got.IsZero() {
+			return
+		}
+		formatted := got.Format(time.RFC3339Nano)
+		again := ParseFlexibleTime(formatted)
+		if !again.Equal(got) {
+			t.Errorf("ParseFlexibleTime(%q) = %v, but its RFC3339Nano form %q parses as %v", s, got, formatted, again)
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package riperepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// githubAPIPrefix is where GitHub's REST API lives; downloadURLs under it
+// are release endpoints for -resolve-github-releases.
+const githubAPIPrefix = "https://api.github.com/"
+
+// isGitHubReleaseURL reports whether u is a GitHub releases API endpoint for
+// one release: https://api.github.com/repos/OWNER/REPO/releases/latest,
+// /releases/tags/TAG or /releases/ID with a numeric ID. Anything else,
+// including github.com pages and .../releases/download/TAG/ASSET links that
+// already name an asset, is left alone.
+func isGitHubReleaseURL(u string) bool {
+	if !strings.HasPrefix(u, githubAPIPrefix) {
+		return false
+	}
+	p, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	parts := strings.Split(strings.TrimPrefix(p.Path, "/"), "/")
+	if len(parts) < 5 || parts[0] != "repos" || parts[1] == "" || parts[2] == "" || parts[3] != "releases" {
+		return false
+	}
+	switch len(parts) {
+	case 5:
+		return parts[4] == "latest" || isDigits(parts[4])
+	case 6:
+		return parts[4] == "tags" && parts[5] != ""
+	}
+	return false
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits.
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// githubRelease is the part of a GitHub release we use.
+type githubRelease struct {
+	Assets []struct {
+		Name               string `json:"name"`
+		BrowserDownloadURL string `json:"browser_download_url"`
+		Size               int64  `json:"size"`
+	} `json:"assets"`
+}
+
+// resolveGitHubReleases rewrites downloadURLs that are GitHub release API
+// endpoints to the release's first .ipa (or .tipa) asset, taking the size
+// from the asset too. Each endpoint is queried once, with token as a bearer
+// token if set. Releases without an IPA asset, and failed queries, leave the
+// URL unchanged with a warning. Nothing is cached: "latest" moves.
+func resolveGitHubReleases(ctx context.Context, client *http.Client, out *Root, opts Options, rep *Report) {
+	type target struct {
+		url   string
+		asset string
+		size  int64
+	}
+	byURL := map[string]*target{}
+	var targets []*target
+	for _, app := range out.Apps {
+		for _, v := range app.Versions {
+			if isGitHubReleaseURL(v.DownloadURL) && byURL[v.DownloadURL] == nil {
+				t := &target{url: v.DownloadURL}
+				byURL[t.url] = t
+				targets = append(targets, t)
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	logv.Infof("querying %d GitHub releases", len(targets))
+	errs := runConcurrent(targets, opts.Concurrency, func(t *target) error {
+		rel, err := fetchGitHubRelease(ctx, client, t.url, opts.GitHubToken)
+		if err != nil {
+			return err
+		}
+		for _, a := range rel.Assets {
+			if hasIPAExtension(a.Name) && a.BrowserDownloadURL != "" {
+				t.asset, t.size = a.BrowserDownloadURL, a.Size
+				return nil
+			}
+		}
+		return nil
+	})
+	for i, t := range targets {
+		if errs[i] != nil && !rep.cancelled(errs[i]) {
+			rep.Warnf("github release %s: %v; downloadURL left unchanged", t.url, errs[i])
+		} else if errs[i] == nil && t.asset == "" {
+			rep.Warnf("github release %s: no .ipa asset; downloadURL left unchanged", t.url)
+		}
+	}
+
+	for ai := range out.Apps {
+		app := &out.Apps[ai]
+		for vi := range app.Versions {
+			v := &app.Versions[vi]
+			if t := byURL[v.DownloadURL]; t != nil && t.asset != "" {
+				logv.Debugf("app %q version %q: %s resolved to %s", app.Name, v.Version, v.DownloadURL, t.asset)
+				v.DownloadURL = t.asset
+				if t.size > 0 {
+					v.Size = t.size
+				}
+			}
+		}
+	}
+}
+
+func fetchGitHubRelease(ctx context.Context, client *http.Client, url, token string) (githubRelease, error) {
+	var rel githubRelease
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return rel, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return rel, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			return rel, fmt.Errorf("HTTP %d, rate limited (set GITHUB_TOKEN)", resp.StatusCode)
+		}
+		return rel, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		return rel, fmt.Errorf("decode release: %v", err)
+	}
+	return rel, nil
+}
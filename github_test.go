@@ -0,0 +1,32 @@
+package riperepo
+
+import "testing"
+
+func TestIsGitHubReleaseURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://api.github.com/repos/owner/repo/releases/latest", true},
+		{"https://api.github.com/repos/owner/repo/releases/123456", true},
+		{"https://api.github.com/repos/owner/repo/releases/tags/v1.2.0", true},
+		{"https://api.github.com/repos/owner/repo/releases/latest?per_page=1", true},
+
+		{"https://api.github.com/repos/owner/repo/releases/assets", false},
+		{"https://api.github.com/repos/owner/repo/releases/generate-notes", false},
+		{"https://api.github.com/repos/owner/repo/releases/assets/42", false},
+		{"https://api.github.com/repos/owner/repo/releases/tags/", false},
+		{"https://api.github.com/repos/owner/repo/releases", false},
+		{"https://api.github.com/repos//repo/releases/latest", false},
+		{"https://api.github.com/repos/owner/repo/tags/latest", false},
+		{"https://github.com/owner/repo/releases/latest", false},
+		{"https://github.com/owner/repo/releases/download/v1.0/App.ipa", false},
+		{"https://github.com/owner/repo/blob/main/App.ipa", false},
+		{"http://api.github.com/repos/owner/repo/releases/latest", false},
+	}
+	for _, tt := range tests {
+		if got := isGitHubReleaseURL(tt.url); got != tt.want {
+			t.Errorf("isGitHubReleaseURL(%s) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
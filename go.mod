@@ -1,3 +1,7 @@
-module altstudio-fix
+module github.com/RipeStore/theriperepo
 
 go 1.24.2
+
+require howett.net/plist v1.0.1
+
+require golang.org/x/text v0.30.0
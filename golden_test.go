@@ -0,0 +1,121 @@
+package riperepo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata from the current output")
+
+// TestGolden runs ProcessSource with default options on every
+// testdata/*.input.json and compares the result with the matching
+// .golden.json. Run with -update after an intended change in output.
+func TestGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.input.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no testdata/*.input.json files")
+	}
+	for _, in := range inputs {
+		name := strings.TrimSuffix(filepath.Base(in), ".input.json")
+		t.Run(name, func(t *testing.T) {
+			b, err := ioutil.ReadFile(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _, err := ProcessSource(context.Background(), b, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", name+".golden.json")
+			if *update {
+				if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("output differs from %s (run go test -update if intended)\n%s", golden, diffLines(want, got, 20))
+			}
+		})
+	}
+}
+
+// TestPreserveOrder checks that without sort options apps, versions, news
+// and featured apps come out in input order, while fields are still
+// normalized. testdata/order.input.json lists none of them in a sorted order.
+func TestPreserveOrder(t *testing.T) {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "order.input.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var in struct {
+		FeaturedApps []string
+		Apps         []struct {
+			BundleIdentifier string
+			Versions         []struct{ Version string }
+		}
+		News []struct{ Identifier string }
+	}
+	if err := json.Unmarshal(b, &in); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, opts := range map[string]Options{
+		"defaults":       {},
+		"preserve-order": {PreserveOrder: true, NormalizeVersion: true, DedupFeatured: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			out, _, err := Build(context.Background(), b, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(out.FeaturedApps, in.FeaturedApps) {
+				t.Errorf("featuredApps = %q, want %q", out.FeaturedApps, in.FeaturedApps)
+			}
+			if len(out.Apps) != len(in.Apps) {
+				t.Fatalf("%d apps, want %d", len(out.Apps), len(in.Apps))
+			}
+			for i, app := range out.Apps {
+				if app.BundleIdentifier != in.Apps[i].BundleIdentifier {
+					t.Errorf("app %d = %s, want %s", i, app.BundleIdentifier, in.Apps[i].BundleIdentifier)
+					continue
+				}
+				for j, v := range app.Versions {
+					// compared without the v that NormalizeVersion strips
+					if want := in.Apps[i].Versions[j].Version; trimVersionPrefix(v.Version) != trimVersionPrefix(want) {
+						t.Errorf("%s version %d = %s, want %s", app.BundleIdentifier, j, v.Version, want)
+					}
+					if _, err := time.Parse(time.RFC3339, v.Date); err != nil || !strings.HasSuffix(v.Date, "Z") {
+						t.Errorf("%s version %s: date %q not normalized", app.BundleIdentifier, v.Version, v.Date)
+					}
+				}
+			}
+			for i, n := range out.News {
+				if n.Identifier != in.News[i].Identifier {
+					t.Errorf("news %d = %s, want %s", i, n.Identifier, in.News[i].Identifier)
+				}
+			}
+		})
+	}
+
+	var optErr *OptionError
+	if _, _, err := Build(context.Background(), b, Options{PreserveOrder: true, SortNews: true}); !errors.As(err, &optErr) {
+		t.Errorf("PreserveOrder with SortNews: err = %v, want an *OptionError", err)
+	}
+}
@@ -0,0 +1,121 @@
+package riperepo
+
+import (
+	"bytes"
+	"html/template"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+)
+
+// htmlPage is the data behind htmlTemplate.
+type htmlPage struct {
+	Title    string
+	Subtitle string
+	Groups   []htmlGroup
+}
+
+type htmlGroup struct {
+	Category string
+	Apps     []htmlApp
+}
+
+type htmlApp struct {
+	Name      string
+	Developer string
+	Icon      template.URL // see htmlIconURL
+	Version   string
+	Date      string
+}
+
+// htmlTemplate is self-contained: inline CSS, no scripts, nothing fetched but
+// the icons themselves. html/template escapes every field.
+var htmlTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, system-ui, sans-serif; margin: 2em auto; max-width: 50em; padding: 0 1em; color: #222; }
+h2 { border-bottom: 1px solid #ddd; padding-bottom: .2em; margin-top: 1.6em; }
+ul { list-style: none; padding: 0; }
+li { display: flex; align-items: center; gap: .8em; margin: .6em 0; }
+img, .noicon { width: 48px; height: 48px; border-radius: 11px; background: #eee; flex: none; }
+.dev, .ver { color: #666; font-size: .9em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- if .Subtitle}}
+<p>{{.Subtitle}}</p>
+{{- end}}
+{{- range .Groups}}
+<h2>{{.Category}}</h2>
+<ul>
+{{- range .Apps}}
+<li>{{if .Icon}}<img src="{{.Icon}}" alt="" loading="lazy">{{else}}<span class="noicon"></span>{{end}}
+<div><strong>{{.Name}}</strong>{{if .Developer}} <span class="dev">by {{.Developer}}</span>{{end}}
+{{- if .Version}}<br><span class="ver">{{.Version}}{{if .Date}} &middot; {{.Date}}{{end}}</span>{{end}}</div></li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes a static HTML page listing out.Apps to path: icon, name,
+// developer and latest version, grouped by category in alphabetical order
+// with uncategorized apps last. Apps keep their order within a group.
+func WriteHTML(path string, out Root) error {
+	page := htmlPage{
+		Title:    defaultIfEmpty(out.Name, out.Identifier),
+		Subtitle: out.Subtitle,
+	}
+	byCategory := map[string][]htmlApp{}
+	for _, app := range out.Apps {
+		a := htmlApp{
+			Name:      app.Name,
+			Developer: app.DeveloperName,
+			Icon:      htmlIconURL(app.IconURL),
+		}
+		if v, ok := app.LatestVersion(); ok {
+			a.Version = v.Version
+			a.Date = changelogDate(v, false, time.Time{})
+		}
+		byCategory[app.Category] = append(byCategory[app.Category], a)
+	}
+	categories := make([]string, 0, len(byCategory))
+	for c := range byCategory {
+		categories = append(categories, c)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i] == "" || categories[j] == "" {
+			return categories[j] == ""
+		}
+		return categories[i] < categories[j]
+	})
+	for _, c := range categories {
+		page.Groups = append(page.Groups, htmlGroup{Category: defaultIfEmpty(c, "Uncategorized"), Apps: byCategory[c]})
+	}
+
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, page); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// htmlIconURL keeps http(s) and image data: URLs (as written by
+// -inline-icons) and drops anything else. html/template would otherwise
+// replace data: URLs with "#ZgotmplZ"; they can't run script from an img src.
+// The result is still normalized and attribute-escaped by the template.
+func htmlIconURL(u string) template.URL {
+	for _, prefix := range []string{"https://", "http://", "data:image/"} {
+		if strings.HasPrefix(u, prefix) {
+			return template.URL(u)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,132 @@
+package riperepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// DefaultUserAgent identifies us to hosts that throttle Go's default UA.
+const DefaultUserAgent = ToolName + " (+https://github.com/RipeStore/theriperepo)"
+
+// newHTTPClient builds the one client shared by every network feature. Its
+// probe cache lasts as long as the client, which is one Build call.
+// HTTPTimeout bounds connecting and waiting for response headers, not the
+// body, so large IPA downloads aren't cut off midway.
+func newHTTPClient(opts Options) *http.Client {
+	timeout := opts.HTTPTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ua := opts.UserAgent
+	if ua == "" {
+		ua = DefaultUserAgent
+	}
+	tr := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: timeout}).DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConnsPerHost:   opts.Concurrency,
+	}
+	var rt http.RoundTripper = tr
+	if opts.Retries > 0 {
+		rt = &retryTransport{retries: opts.Retries, next: rt}
+	}
+	rt = &uaTransport{ua: ua, next: rt}
+	return &http.Client{Transport: &probeCacheTransport{next: rt, entries: map[string]*probeEntry{}}}
+}
+
+// retryBaseDelay is the wait before the first retry; it doubles each attempt.
+const retryBaseDelay = 500 * time.Millisecond
+
+// retryTransport retries a request on transient network errors (timeouts,
+// resets, connections closed early) and 429/5xx responses with exponential
+// backoff. Other statuses, 404 included, are returned as-is, as are
+// permanent errors such as a failed certificate check, and nothing is
+// retried once the request's context is done. Our requests never carry a
+// body, so they are safe to resend.
+type retryTransport struct {
+	retries int
+	next    http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	delay := retryBaseDelay
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if attempt == t.retries || !shouldRetry(req, resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if err != nil {
+		return isTransientError(err)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// isTransientError reports whether err is a network failure that may well
+// not happen again: a timeout, a reset or a connection the server closed
+// before answering.
+func isTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// uaTransport sets the User-Agent on every outgoing request.
+type uaTransport struct {
+	ua   string
+	next http.RoundTripper
+}
+
+func (t *uaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.ua)
+	return t.next.RoundTrip(req)
+}
+
+// FetchSource downloads a source document from url with the client the
+// network passes use, so -user-agent, -http-timeout and -retries apply.
+func FetchSource(ctx context.Context, url string, opts Options) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := newHTTPClient(opts).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
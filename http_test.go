@@ -0,0 +1,75 @@
+package riperepo
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+)
+
+func TestShouldRetry(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/a.ipa", nil)
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	reqCancelled := req.WithContext(cancelled)
+
+	status := func(code int) *http.Response { return &http.Response{StatusCode: code} }
+	tests := []struct {
+		name string
+		req  *http.Request
+		resp *http.Response
+		err  error
+		want bool
+	}{
+		{"503", req, status(503), nil, true},
+		{"429", req, status(429), nil, true},
+		{"404", req, status(404), nil, false},
+		{"200", req, status(200), nil, false},
+		{"connection reset", req, nil, &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{"closed early", req, nil, fmt.Errorf("reading response: %w", io.ErrUnexpectedEOF), true},
+		{"dial timeout", req, nil, &net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded}, true},
+		{"bad certificate", req, nil, x509.UnknownAuthorityError{}, false},
+		{"bad URL", req, nil, &url.Error{Op: "parse", URL: "::", Err: errors.New("missing protocol scheme")}, false},
+		{"context cancelled", req, nil, context.Canceled, false},
+		{"503 after cancel", reqCancelled, status(503), nil, false},
+		{"reset after cancel", reqCancelled, nil, &net.OpError{Op: "read", Err: syscall.ECONNRESET}, false},
+	}
+	for _, tt := range tests {
+		if got := shouldRetry(tt.req, tt.resp, tt.err); got != tt.want {
+			t.Errorf("%s: shouldRetry = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRetryTransportCertificateError(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+	var attempts atomic.Int32
+	tr := &retryTransport{retries: 3, next: &countingTransport{next: http.DefaultTransport, n: &attempts}}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if _, err := tr.RoundTrip(req); err == nil {
+		t.Fatal("untrusted certificate accepted")
+	}
+	if n := attempts.Load(); n != 1 {
+		t.Errorf("%d attempts, want 1", n)
+	}
+}
+
+type countingTransport struct {
+	next http.RoundTripper
+	n    *atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.n.Add(1)
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,86 @@
+package riperepo
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// probeCacheTransport remembers the status and headers of HEAD requests and
+// single-byte ranged GETs (the probes -check-urls and -resolve-downloads
+// send), so a URL probed by several passes is only fetched once per hop of
+// its redirect chain. Cached responses have an empty body; probes never read
+// it. Full downloads go straight through. The cache lives in memory for one
+// run and is never persisted. Failed requests aren't cached, but callers
+// waiting on the same in-flight probe share its outcome.
+type probeCacheTransport struct {
+	next    http.RoundTripper
+	mu      sync.Mutex
+	entries map[string]*probeEntry
+}
+
+type probeEntry struct {
+	done   chan struct{}
+	status int
+	header http.Header
+	err    error
+}
+
+func (t *probeCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rng := req.Header.Get("Range")
+	if req.Method != http.MethodHead && !(req.Method == http.MethodGet && rng == "bytes=0-0") {
+		return t.next.RoundTrip(req)
+	}
+	key := req.Method + " " + rng + " " + req.URL.String()
+
+	t.mu.Lock()
+	e, ok := t.entries[key]
+	if !ok {
+		e = &probeEntry{done: make(chan struct{})}
+		t.entries[key] = e
+	}
+	t.mu.Unlock()
+
+	if ok {
+		select {
+		case <-e.done:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		if e.err != nil {
+			return nil, e.err
+		}
+		logv.Debugf("%s %s: cached HTTP %d", req.Method, req.URL, e.status)
+		return cachedResponse(req, e), nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		e.err = err
+		// let a later probe try again
+		t.mu.Lock()
+		delete(t.entries, key)
+		t.mu.Unlock()
+		close(e.done)
+		return nil, err
+	}
+	resp.Body.Close()
+	e.status, e.header = resp.StatusCode, resp.Header.Clone()
+	close(e.done)
+	return cachedResponse(req, e), nil
+}
+
+func cachedResponse(req *http.Request, e *probeEntry) *http.Response {
+	return &http.Response{
+		Status:        http.StatusText(e.status),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(strings.NewReader("")),
+		ContentLength: -1,
+		Request:       req,
+	}
+}
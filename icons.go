@@ -0,0 +1,144 @@
+package riperepo
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif" // register decoders for image.DecodeConfig
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// checkIconSizes fetches the header of every app's IconURL and warns when
+// the image is narrower or shorter than minSize pixels. Only as many bytes as
+// image.DecodeConfig needs are read before the connection is dropped.
+func checkIconSizes(ctx context.Context, client *http.Client, out Root, minSize int, opts Options, rep *Report) {
+	type target struct {
+		app           string
+		url           string
+		width, height int
+	}
+	var targets []*target
+	for _, app := range out.Apps {
+		if app.IconURL != "" {
+			targets = append(targets, &target{app: app.Name, url: app.IconURL})
+		}
+	}
+
+	logv.Infof("measuring %d icons", len(targets))
+	errs := runConcurrent(targets, opts.Concurrency, func(t *target) (err error) {
+		t.width, t.height, err = fetchImageSize(ctx, client, t.url)
+		return err
+	})
+
+	for i, t := range targets {
+		if errs[i] != nil {
+			if !rep.cancelled(errs[i]) {
+				rep.Warnf("app %q: iconURL %s: can't read dimensions: %v", t.app, t.url, errs[i])
+			}
+			continue
+		}
+		if t.width < minSize || t.height < minSize {
+			rep.Warnf("app %q: icon is %dx%d, smaller than %dx%d", t.app, t.width, t.height, minSize, minSize)
+		}
+	}
+}
+
+// fetchImageSize reads an image's dimensions from the start of its body.
+func fetchImageSize(ctx context.Context, client *http.Client, url string) (width, height int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return 0, 0, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	cfg, _, err := image.DecodeConfig(resp.Body)
+	if err != nil {
+		return 0, 0, err
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// inlineIcons replaces the source's and every app's IconURL with a base64
+// data: URL of the downloaded image. Icons larger than maxSize bytes, or
+// that fail to download or aren't images, keep their URL with a warning.
+func inlineIcons(ctx context.Context, client *http.Client, out *Root, maxSize int64, opts Options, rep *Report) {
+	type target struct {
+		what string
+		url  *string
+		data string
+	}
+	var targets []*target
+	if out.IconURL != "" {
+		targets = append(targets, &target{what: "source", url: &out.IconURL})
+	}
+	for i := range out.Apps {
+		if out.Apps[i].IconURL != "" {
+			targets = append(targets, &target{what: fmt.Sprintf("app %q", out.Apps[i].Name), url: &out.Apps[i].IconURL})
+		}
+	}
+
+	logv.Infof("inlining %d icons", len(targets))
+	errs := runConcurrent(targets, opts.Concurrency, func(t *target) (err error) {
+		t.data, err = fetchDataURL(ctx, client, *t.url, maxSize)
+		return err
+	})
+
+	for i, t := range targets {
+		if errs[i] != nil {
+			if !rep.cancelled(errs[i]) {
+				rep.Warnf("%s: iconURL %s not inlined: %v", t.what, *t.url, errs[i])
+			}
+			continue
+		}
+		*t.url = t.data
+	}
+}
+
+// fetchDataURL downloads url, refusing more than maxSize bytes, and encodes
+// it as a data: URL. The MIME type comes from Content-Type when that names
+// an image, and is sniffed from the bytes otherwise.
+func fetchDataURL(ctx context.Context, client *http.Client, url string, maxSize int64) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxSize {
+		return "", fmt.Errorf("%d bytes is over the %d byte limit", resp.ContentLength, maxSize)
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(b)) > maxSize {
+		return "", fmt.Errorf("over the %d byte limit", maxSize)
+	}
+	mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mt, "image/") {
+		mt, _, _ = mime.ParseMediaType(http.DetectContentType(b))
+	}
+	if !strings.HasPrefix(mt, "image/") {
+		return "", fmt.Errorf("content is %s, not an image", mt)
+	}
+	return "data:" + mt + ";base64," + base64.StdEncoding.EncodeToString(b), nil
+}
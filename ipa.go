@@ -0,0 +1,157 @@
+package riperepo
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"howett.net/plist"
+)
+
+// ipaInfo holds the Info.plist keys we compare against the source.
+type ipaInfo struct {
+	BundleIdentifier string `plist:"CFBundleIdentifier"`
+	ShortVersion     string `plist:"CFBundleShortVersionString"`
+	SHA256           string `plist:"-"` // of the whole IPA, set by fetchIPAInfo
+}
+
+// verifyIPAs downloads every version's IPA and warns when the bundle
+// identifier or version in its Info.plist, or the file's SHA-256, differs from
+// the declared values. Values found in the cache are used without downloading.
+func verifyIPAs(ctx context.Context, client *http.Client, cache *metaCache, out Root, opts Options, rep *Report) {
+	type target struct {
+		app     App
+		version Version
+		info    ipaInfo
+	}
+	var targets []*target
+	for _, app := range out.Apps {
+		for _, v := range app.Versions {
+			if v.DownloadURL != "" {
+				targets = append(targets, &target{app: app, version: v})
+			}
+		}
+	}
+
+	logv.Infof("verifying %d IPAs", len(targets))
+	errs := runConcurrent(targets, opts.Concurrency, func(t *target) (err error) {
+		url := t.version.DownloadURL
+		// entries cached before checksums were recorded are fetched again
+		if m := cache.get(url); m.IPASHA256 != "" {
+			t.info = ipaInfo{BundleIdentifier: m.IPABundleID, ShortVersion: m.IPAVersion, SHA256: m.IPASHA256}
+			return nil
+		}
+		if t.info, err = fetchIPAInfo(ctx, client, url, opts.MaxIPASize); err == nil {
+			cache.update(url, func(m *urlMeta) {
+				m.IPABundleID, m.IPAVersion, m.IPASHA256 = t.info.BundleIdentifier, t.info.ShortVersion, t.info.SHA256
+			})
+		}
+		return err
+	})
+
+	for i, t := range targets {
+		if errs[i] != nil {
+			if rep.cancelled(errs[i]) {
+				continue
+			}
+			rep.Warnf("app %q version %q: verify ipa: %v", t.app.Name, t.version.Version, errs[i])
+			continue
+		}
+		info := t.info
+		if info.BundleIdentifier != t.app.BundleIdentifier {
+			rep.Warnf("app %q version %q: ipa CFBundleIdentifier %q != bundleIdentifier %q",
+				t.app.Name, t.version.Version, info.BundleIdentifier, t.app.BundleIdentifier)
+		}
+		if info.ShortVersion != t.version.Version {
+			rep.Warnf("app %q version %q: ipa CFBundleShortVersionString %q != version %q",
+				t.app.Name, t.version.Version, info.ShortVersion, t.version.Version)
+		}
+		if t.version.SHA256 != "" && info.SHA256 != t.version.SHA256 {
+			rep.Warnf("app %q version %q: ipa sha256 %s != declared sha256 %s",
+				t.app.Name, t.version.Version, info.SHA256, t.version.SHA256)
+		}
+	}
+}
+
+// fetchIPAInfo streams the IPA at url into a temp file, refusing anything
+// larger than maxSize bytes, hashes it, and reads the app's Info.plist from
+// it. zip needs random access to the central directory, hence the temp file.
+func fetchIPAInfo(ctx context.Context, client *http.Client, url string, maxSize int64) (ipaInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return ipaInfo{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ipaInfo{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return ipaInfo{}, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxSize {
+		return ipaInfo{}, fmt.Errorf("ipa is %d bytes, over the %d byte limit", resp.ContentLength, maxSize)
+	}
+
+	f, err := ioutil.TempFile("", "verify-*.ipa")
+	if err != nil {
+		return ipaInfo{}, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	// read one byte past the limit so an oversized body is detectable
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return ipaInfo{}, err
+	}
+	if n > maxSize {
+		return ipaInfo{}, fmt.Errorf("ipa exceeds the %d byte limit", maxSize)
+	}
+
+	zr, err := zip.NewReader(f, n)
+	if err != nil {
+		return ipaInfo{}, err
+	}
+	info, err := readIPAInfo(zr)
+	info.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return info, err
+}
+
+// readIPAInfo decodes Payload/<name>.app/Info.plist, which may be XML or binary.
+func readIPAInfo(zr *zip.Reader) (ipaInfo, error) {
+	for _, zf := range zr.File {
+		dir, file := path.Split(zf.Name)
+		if file != "Info.plist" || !strings.HasPrefix(dir, "Payload/") {
+			continue
+		}
+		// only the top-level bundle, not frameworks or plugins nested inside it
+		if strings.Count(dir, "/") != 2 || !strings.HasSuffix(dir, ".app/") {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return ipaInfo{}, err
+		}
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return ipaInfo{}, err
+		}
+		var info ipaInfo
+		if _, err := plist.Unmarshal(data, &info); err != nil {
+			return ipaInfo{}, fmt.Errorf("%s: %v", zf.Name, err)
+		}
+		return info, nil
+	}
+	return ipaInfo{}, fmt.Errorf("no Payload/*.app/Info.plist in archive")
+}
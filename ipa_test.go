@@ -0,0 +1,101 @@
+package riperepo
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// buildIPA returns a minimal IPA whose Info.plist declares bundleID and
+// version.
+func buildIPA(t *testing.T, bundleID, version string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("Payload/Test.app/Info.plist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict>
+<key>CFBundleIdentifier</key><string>` + bundleID + `</string>
+<key>CFBundleShortVersionString</key><string>` + version + `</string>
+</dict></plist>`))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSHA256Passthrough(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	out, rep := decodeString(t, `{"apps": [{"name": "A", "versions": [
+		{"version": "1", "sha256": " `+strings.ToUpper(sum)+` "},
+		{"version": "2", "sha256": "not-a-digest"},
+		{"version": "3"}
+	]}]}`)
+	v := out.Apps[0].Versions
+	if v[0].SHA256 != sum {
+		t.Errorf("sha256 = %q, want it trimmed and lowercased to %q", v[0].SHA256, sum)
+	}
+	if v[1].SHA256 != "not-a-digest" || len(rep.Warnings) != 1 || !strings.Contains(rep.Warnings[0], "not 64 hex digits") {
+		t.Errorf("malformed sha256 = %q, warnings %q; want it kept with one warning", v[1].SHA256, rep.Warnings)
+	}
+	if v[2].SHA256 != "" {
+		t.Errorf("sha256 = %q, want none", v[2].SHA256)
+	}
+
+	b, _, err := ProcessSource(context.Background(), []byte(`{"apps": [{"name": "A", "versions": [
+		{"version": "1", "sha256": "`+sum+`"}]}]}`), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(b, []byte(`"sha256": "`+sum+`"`)) {
+		t.Errorf("sha256 missing from output:\n%s", b)
+	}
+}
+
+func TestVerifyIPASHA256(t *testing.T) {
+	ipa := buildIPA(t, "com.a", "1.0")
+	digest := sha256.Sum256(ipa)
+	good := hex.EncodeToString(digest[:])
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(ipa)
+	}))
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		name, sha256 string
+		mismatch     bool
+	}{
+		{"matching", good, false},
+		{"mismatched", strings.Repeat("0", 64), true},
+		{"undeclared", "", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			src := `{"apps": [{"name": "A", "bundleIdentifier": "com.a", "screenshotURLs": ["https://example.com/1.png"],
+				"versions": [{"version": "1.0", "downloadURL": "` + srv.URL + `/a.ipa", "sha256": "` + tt.sha256 + `"}]}]}`
+			_, rep, err := Build(context.Background(), []byte(src), Options{VerifyIPA: true, MaxIPASize: 1 << 20})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var mismatch bool
+			for _, w := range rep.Warnings {
+				if strings.Contains(w, "!= declared sha256") {
+					mismatch = true
+				} else {
+					t.Errorf("unexpected warning: %s", w)
+				}
+			}
+			if mismatch != tt.mismatch {
+				t.Errorf("mismatch warned = %v, want %v (warnings %q)", mismatch, tt.mismatch, rep.Warnings)
+			}
+		})
+	}
+}
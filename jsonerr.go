@@ -0,0 +1,60 @@
+package riperepo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// locateJSONError turns the byte offset of a JSON syntax or type error into a
+// line and column in b, followed by the offending line and a caret under the
+// column. Other errors are returned unchanged.
+func locateJSONError(b []byte, err error) error {
+	var offset int64
+	var syn *json.SyntaxError
+	var typ *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syn):
+		offset = syn.Offset
+	case errors.As(err, &typ):
+		offset = typ.Offset
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		offset = int64(len(b))
+	default:
+		return err
+	}
+	// offsets count the byte that failed; point at it
+	pos := int(offset)
+	if pos > 0 && !errors.Is(err, io.ErrUnexpectedEOF) {
+		pos--
+	}
+	if pos > len(b) {
+		pos = len(b)
+	}
+	start := strings.LastIndexByte(string(b[:pos]), '\n') + 1
+	end := len(b)
+	if i := strings.IndexByte(string(b[pos:]), '\n'); i >= 0 {
+		end = pos + i
+	}
+	line := strings.Count(string(b[:start]), "\n") + 1
+	col := utf8.RuneCount(b[start:pos]) + 1
+
+	// minified sources are one huge line; show a window around the error
+	const window = 40
+	text, caret := string(b[start:end]), col-1
+	if caret > window {
+		cut := pos - window
+		for cut > start && !utf8.RuneStart(b[cut]) {
+			cut--
+		}
+		text, caret = "..."+string(b[cut:end]), utf8.RuneCount(b[cut:pos])+3
+	}
+	if r := []rune(text); len(r) > caret+window {
+		text = string(r[:caret+window]) + "..."
+	}
+	text = strings.TrimRight(strings.ReplaceAll(text, "\t", " "), "\r")
+	return fmt.Errorf("line %d, column %d: %w\n  %s\n  %s^", line, col, err, text, strings.Repeat(" ", caret))
+}
@@ -0,0 +1,55 @@
+package riperepo
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestStripLenient(t *testing.T) {
+	tests := []struct {
+		name, in, want string // want is the JSON the stripped input must equal
+	}{
+		{"line comment", "{\"a\": 1 // one\n}", `{"a": 1}`},
+		{"block comment", "{/* lead */\"a\": /* mid\n */ 1}", `{"a": 1}`},
+		{"trailing comma in object", `{"a": 1, "b": 2,}`, `{"a": 1, "b": 2}`},
+		{"trailing comma in array", `{"a": [1, 2, ]}`, `{"a": [1, 2]}`},
+		{"trailing comma then comment", "{\"a\": [1, // last\n], \"b\": 2, /* end */}", `{"a": [1], "b": 2}`},
+		{"comment markers in strings", `{"url": "https://example.com/a//b", "note": "/* not a comment */"}`,
+			`{"url": "https://example.com/a//b", "note": "/* not a comment */"}`},
+		{"comma in string before brace", `{"s": "a,}", "t": ",]"}`, `{"s": "a,}", "t": ",]"}`},
+		{"escaped quote in string", `{"s": "say \"hi\" // still text",}`, `{"s": "say \"hi\" // still text"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stripped := stripLenient([]byte(tt.in))
+			if len(stripped) != len(tt.in) {
+				t.Errorf("length changed from %d to %d; error offsets would shift", len(tt.in), len(stripped))
+			}
+			var got, want interface{}
+			if err := json.Unmarshal(stripped, &got); err != nil {
+				t.Fatalf("stripped input %q doesn't parse: %v", stripped, err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestLenientIsOptIn(t *testing.T) {
+	src := []byte("{\n  // hand-edited\n  \"name\": \"Repo\",\n}")
+	if _, err := decodeRaw(src, Options{}, &Report{}); err == nil {
+		t.Error("strict parse accepted a comment and a trailing comma")
+	}
+	out, err := decodeRaw(src, Options{Lenient: true}, &Report{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "Repo" {
+		t.Errorf("name = %q, want Repo", out.Name)
+	}
+}
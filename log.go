@@ -0,0 +1,49 @@
+package riperepo
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Log levels, from least to most chatty.
+const (
+	LevelQuiet = iota // errors only
+	LevelInfo         // default: progress notes
+	LevelDebug        // per-app and per-version decisions
+)
+
+// logger is a minimal leveled logger.
+type logger struct {
+	w     io.Writer
+	level int
+}
+
+// logv is the process-wide logger. It is quiet until the caller raises the
+// level, so importing the package writes nothing; the CLI sets the level from
+// -quiet/-verbose.
+var logv = &logger{w: os.Stderr, level: LevelQuiet}
+
+// SetLogLevel sets how much progress logging is written.
+func SetLogLevel(level int) {
+	logv.level = level
+}
+
+// SetLogOutput sets where progress logging goes; the default is stderr.
+func SetLogOutput(w io.Writer) {
+	logv.w = w
+}
+
+func (l *logger) Infof(format string, args ...interface{}) {
+	l.logf(LevelInfo, format, args...)
+}
+
+func (l *logger) Debugf(format string, args ...interface{}) {
+	l.logf(LevelDebug, format, args...)
+}
+
+func (l *logger) logf(level int, format string, args ...interface{}) {
+	if l.level >= level {
+		fmt.Fprintf(l.w, format+"\n", args...)
+	}
+}
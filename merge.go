@@ -0,0 +1,161 @@
+package riperepo
+
+import (
+	"bytes"
+	"errors"
+)
+
+// decodeNDJSON decodes one source per line and merges them in order. Blank
+// lines are skipped; a malformed line is reported with its line number and
+// the rest are still merged.
+func decodeNDJSON(b []byte, rep *Report) (Root, error) {
+	var out Root
+	merged := 0
+	for i, line := range bytes.Split(b, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		src, err := decodeSource(line, rep)
+		if err != nil {
+			rep.Warnf("ndjson line %d: %v", i+1, err)
+			continue
+		}
+		mergeRoots(&out, src)
+		merged++
+	}
+	if merged == 0 {
+		return Root{}, errors.New("no valid sources in NDJSON input")
+	}
+	return out, nil
+}
+
+// mergeRoots folds src into dst, treating src as the newer source:
+//   - non-empty top-level fields in src replace those in dst
+//   - featuredApps are unioned in first-seen order
+//   - apps are matched by bundleIdentifier and merged with mergeApp; unmatched
+//     apps (and apps without an identifier) are appended
+//   - news items are matched by identifier and replaced in place; new ones
+//     are appended
+func mergeRoots(dst *Root, src Root) {
+	mergeStr(&dst.Name, src.Name)
+	mergeStr(&dst.Subtitle, src.Subtitle)
+	mergeStr(&dst.Identifier, src.Identifier)
+	mergeStr(&dst.SourceURL, src.SourceURL)
+	mergeStr(&dst.Description, src.Description)
+	mergeStr(&dst.IconURL, src.IconURL)
+	mergeStr(&dst.Website, src.Website)
+	mergeStr(&dst.PatreonURL, src.PatreonURL)
+	mergeStr(&dst.HeaderURL, src.HeaderURL)
+	mergeStr(&dst.TintColor, src.TintColor)
+
+	seen := make(map[string]bool, len(dst.FeaturedApps))
+	for _, id := range dst.FeaturedApps {
+		seen[id] = true
+	}
+	for _, id := range src.FeaturedApps {
+		if !seen[id] {
+			seen[id] = true
+			dst.FeaturedApps = append(dst.FeaturedApps, id)
+		}
+	}
+
+	for _, app := range src.Apps {
+		if i := findApp(dst.Apps, app.BundleIdentifier); i >= 0 {
+			mergeApp(&dst.Apps[i], app)
+		} else {
+			dst.Apps = append(dst.Apps, app)
+		}
+	}
+
+	for _, n := range src.News {
+		replaced := false
+		if n.Identifier != "" {
+			for i := range dst.News {
+				if dst.News[i].Identifier == n.Identifier {
+					dst.News[i] = n
+					replaced = true
+					break
+				}
+			}
+		}
+		if !replaced {
+			dst.News = append(dst.News, n)
+		}
+	}
+}
+
+// mergeApp folds the newer app src into dst. Non-empty fields in src win.
+// The merged version list is src's versions followed by any of dst's that
+// src doesn't list, so the newest snapshot's order is kept.
+func mergeApp(dst *App, src App) {
+	mergeStr(&dst.Name, src.Name)
+	mergeStr(&dst.DeveloperName, src.DeveloperName)
+	mergeStr(&dst.Subtitle, src.Subtitle)
+	mergeStr(&dst.LocalizedDescription, src.LocalizedDescription)
+	mergeLocalized(&dst.LocalizedNames, src.LocalizedNames)
+	mergeLocalized(&dst.LocalizedSubtitles, src.LocalizedSubtitles)
+	mergeLocalized(&dst.LocalizedDescriptions, src.LocalizedDescriptions)
+	mergeStr(&dst.IconURL, src.IconURL)
+	mergeStr(&dst.TintColor, src.TintColor)
+	mergeStr(&dst.Category, src.Category)
+	mergeStr(&dst.VideoURL, src.VideoURL)
+	if len(src.ScreenshotURLs) > 0 {
+		dst.ScreenshotURLs = src.ScreenshotURLs
+	}
+	if src.Beta {
+		dst.Beta = true
+	}
+	if src.patreonGated {
+		dst.patreonGated = true
+	}
+	if len(src.AppPermissions) > 0 {
+		dst.AppPermissions = src.AppPermissions
+	}
+
+	versions := append([]Version(nil), src.Versions...)
+	have := make(map[string]bool, len(src.Versions))
+	for _, v := range src.Versions {
+		have[v.Version] = true
+	}
+	for _, v := range dst.Versions {
+		if !have[v.Version] {
+			versions = append(versions, v)
+		}
+	}
+	dst.Versions = versions
+}
+
+// findApp returns the index of the app with the given bundle identifier, or
+// -1. An empty identifier never matches.
+func findApp(apps []App, bundleID string) int {
+	if bundleID == "" {
+		return -1
+	}
+	for i := range apps {
+		if apps[i].BundleIdentifier == bundleID {
+			return i
+		}
+	}
+	return -1
+}
+
+func mergeStr(dst *string, src string) {
+	if src != "" {
+		*dst = src
+	}
+}
+
+// mergeLocalized adds src's locales to dst, replacing ones both have.
+func mergeLocalized(dst *map[string]string, src map[string]string) {
+	if len(src) == 0 {
+		return
+	}
+	merged := make(map[string]string, len(*dst)+len(src))
+	for k, v := range *dst {
+		merged[k] = v
+	}
+	for k, v := range src {
+		merged[k] = v
+	}
+	*dst = merged
+}
@@ -0,0 +1,26 @@
+package riperepo
+
+import "testing"
+
+func TestDecodeNDJSONMergesApps(t *testing.T) {
+	rep := &Report{}
+	out, err := decodeNDJSON([]byte(`{"name": "Repo", "apps": [{"name": "A", "bundleIdentifier": "com.example.a", "versions": [{"version": "1.0", "downloadURL": "https://example.com/a1.ipa", "size": 1}]}]}
+{"apps": [{"bundleIdentifier": "com.example.a", "videoURL": "https://example.com/a.mp4", "versions": [{"version": "1.1", "downloadURL": "https://example.com/a2.ipa", "size": 1}]}]}
+`), rep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Apps) != 1 {
+		t.Fatalf("got %d apps, want 1", len(out.Apps))
+	}
+	app := out.Apps[0]
+	if app.Name != "A" {
+		t.Errorf("name = %q, want the first source's %q", app.Name, "A")
+	}
+	if app.VideoURL != "https://example.com/a.mp4" {
+		t.Errorf("videoURL = %q, want the second source's", app.VideoURL)
+	}
+	if len(app.Versions) != 2 || app.Versions[0].Version != "1.1" {
+		t.Errorf("versions = %+v, want 1.1 then 1.0", app.Versions)
+	}
+}
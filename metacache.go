@@ -0,0 +1,95 @@
+package riperepo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// metaCacheFile is the cache file's name inside Options.CacheDir.
+const metaCacheFile = "remote-metadata.json"
+
+// urlMeta is what the network passes learned about one download URL. Only
+// successes are recorded, so a broken URL is checked again next run.
+// Reachability and redirect targets are not kept: a link that worked
+// yesterday can be dead today, see checkDownloadURLs and resolveDownloadURLs.
+type urlMeta struct {
+	IPABundleID string `json:"ipaBundleID,omitempty"` // -verify-ipa Info.plist values
+	IPAVersion  string `json:"ipaVersion,omitempty"`
+	IPASHA256   string `json:"ipaSHA256,omitempty"`
+}
+
+// metaCache is an on-disk record of urlMeta keyed by download URL, so
+// scheduled runs don't refetch what can't have changed. Entries are keyed by
+// the exact URL: a version whose URL changes is looked up afresh. A nil
+// *metaCache caches nothing.
+type metaCache struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]urlMeta
+	dirty   bool
+}
+
+// openMetaCache loads the cache from dir, starting empty if there is no cache
+// file yet or refresh is set; new results are saved either way. It returns
+// nil when dir is "".
+func openMetaCache(dir string, refresh bool) (*metaCache, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	c := &metaCache{path: filepath.Join(dir, metaCacheFile), entries: map[string]urlMeta{}}
+	if refresh {
+		return c, nil
+	}
+	b, err := ioutil.ReadFile(c.path)
+	switch {
+	case os.IsNotExist(err):
+		return c, nil
+	case err != nil:
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &c.entries); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// get returns the cached metadata for url.
+func (c *metaCache) get(url string) urlMeta {
+	if c == nil {
+		return urlMeta{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[url]
+}
+
+// update applies fn to the cached metadata for url.
+func (c *metaCache) update(url string, fn func(*urlMeta)) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m := c.entries[url]
+	fn(&m)
+	c.entries[url] = m
+	c.dirty = true
+}
+
+// save writes the cache back if anything changed.
+func (c *metaCache) save() error {
+	if c == nil || !c.dirty {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, b, 0644)
+}
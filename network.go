@@ -0,0 +1,200 @@
+package riperepo
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// checkDownloadURLs probes every version's DownloadURL concurrently and
+// records a warning for each one that fails to connect or returns non-2xx.
+// Every URL is probed on every run, -cache-dir or not, so a link that goes
+// dead is reported the next time.
+func checkDownloadURLs(ctx context.Context, client *http.Client, out Root, opts Options, rep *Report) {
+	type target struct {
+		app, version, url string
+	}
+	var targets []target
+	for _, app := range out.Apps {
+		for _, v := range app.Versions {
+			if v.DownloadURL != "" {
+				targets = append(targets, target{app.Name, v.Version, v.DownloadURL})
+			}
+		}
+	}
+
+	logv.Infof("checking %d download URLs", len(targets))
+	errs := runConcurrent(targets, opts.Concurrency, func(t target) error {
+		return probeURL(ctx, client, t.url)
+	})
+
+	// report in source order so the warning list is stable between runs
+	for i, err := range errs {
+		if err != nil && !rep.cancelled(err) {
+			t := targets[i]
+			rep.Warnf("app %q version %q: %s: %v", t.app, t.version, t.url, err)
+		}
+	}
+}
+
+// checkIconURLs probes the source's and every app's IconURL and warns when it
+// doesn't answer 2xx or its Content-Type isn't an image, as when a host
+// serves an HTML error page.
+func checkIconURLs(ctx context.Context, client *http.Client, out Root, opts Options, rep *Report) {
+	type target struct {
+		what, url string
+	}
+	var targets []target
+	if out.IconURL != "" {
+		targets = append(targets, target{"source", out.IconURL})
+	}
+	for _, app := range out.Apps {
+		if app.IconURL != "" {
+			targets = append(targets, target{fmt.Sprintf("app %q", app.Name), app.IconURL})
+		}
+	}
+
+	logv.Infof("checking %d icon URLs", len(targets))
+	errs := runConcurrent(targets, opts.Concurrency, func(t target) error {
+		h, err := probeHeader(ctx, client, t.url)
+		if err != nil {
+			return err
+		}
+		ct := h.Get("Content-Type")
+		if mt, _, err := mime.ParseMediaType(ct); err != nil || !strings.HasPrefix(mt, "image/") {
+			return fmt.Errorf("Content-Type %q is not an image", ct)
+		}
+		return nil
+	})
+
+	for i, err := range errs {
+		if err != nil && !rep.cancelled(err) {
+			rep.Warnf("%s: iconURL %s: %v", targets[i].what, targets[i].url, err)
+		}
+	}
+}
+
+// resolveDownloadURLs follows redirects on every version's DownloadURL and
+// replaces it with the final URL, so clients that don't follow redirects can
+// still install. URLs that fail to resolve are left unchanged with a warning.
+// Nothing is cached: a redirect such as /latest.ipa moves while the URL
+// that names it stays the same.
+func resolveDownloadURLs(ctx context.Context, client *http.Client, out *Root, opts Options, rep *Report) {
+	type target struct {
+		app   string
+		v     *Version
+		final string
+	}
+	var targets []*target
+	for ai := range out.Apps {
+		for vi := range out.Apps[ai].Versions {
+			if out.Apps[ai].Versions[vi].DownloadURL != "" {
+				targets = append(targets, &target{app: out.Apps[ai].Name, v: &out.Apps[ai].Versions[vi]})
+			}
+		}
+	}
+
+	logv.Infof("resolving %d download URLs", len(targets))
+	errs := runConcurrent(targets, opts.Concurrency, func(t *target) (err error) {
+		t.final, err = resolveURL(ctx, client, t.v.DownloadURL)
+		return err
+	})
+
+	for i, t := range targets {
+		if errs[i] != nil {
+			if rep.cancelled(errs[i]) {
+				continue
+			}
+			rep.Warnf("app %q version %q: resolve %s: %v", t.app, t.v.Version, t.v.DownloadURL, errs[i])
+			continue
+		}
+		if t.final != t.v.DownloadURL {
+			logv.Debugf("app %q version %q: %s resolved to %s", t.app, t.v.Version, t.v.DownloadURL, t.final)
+		}
+		t.v.DownloadURL = t.final
+	}
+}
+
+// resolveURL issues a ranged GET with redirect-following and returns the URL
+// of the final response.
+func resolveURL(ctx context.Context, client *http.Client, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return resp.Request.URL.String(), nil
+}
+
+// probeURL checks that url answers with a 2xx status. Some hosts refuse HEAD,
+// so a failed HEAD is retried as a GET for the first byte only.
+func probeURL(ctx context.Context, client *http.Client, url string) error {
+	_, err := probeHeader(ctx, client, url)
+	return err
+}
+
+// probeHeader is probeURL that also returns the response headers.
+func probeHeader(ctx context.Context, client *http.Client, url string) (http.Header, error) {
+	status, h, err := doProbe(ctx, client, http.MethodHead, url)
+	if err == nil && status/100 == 2 {
+		return h, nil
+	}
+	status, h, err = doProbe(ctx, client, http.MethodGet, url)
+	if err != nil {
+		return nil, err
+	}
+	if status/100 != 2 {
+		return nil, fmt.Errorf("HTTP %d", status)
+	}
+	return h, nil
+}
+
+func doProbe(ctx context.Context, client *http.Client, method, url string) (int, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, resp.Header, nil
+}
+
+// runConcurrent calls fn on every item, running at most limit calls at once,
+// and returns when all have finished. errs[i] is what fn returned for
+// items[i], so callers can report failures in source order.
+func runConcurrent[T any](items []T, limit int, fn func(T) error) (errs []error) {
+	if limit < 1 {
+		limit = 1
+	}
+	errs = make([]error, len(items))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = fn(item)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
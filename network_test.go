@@ -0,0 +1,116 @@
+package riperepo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestResolveDownloadsNotCached(t *testing.T) {
+	var target atomic.Value
+	target.Store("/v1.ipa")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latest.ipa" {
+			http.Redirect(w, r, target.Load().(string), http.StatusFound)
+			return
+		}
+		w.Write([]byte("PK"))
+	}))
+	defer srv.Close()
+
+	src := []byte(`{"apps": [{"name": "A", "bundleIdentifier": "com.a", "screenshotURLs": ["https://example.com/1.png"],
+		"versions": [{"version": "1", "downloadURL": "` + srv.URL + `/latest.ipa"}]}]}`)
+	opts := Options{ResolveDownloads: true, CacheDir: t.TempDir()}
+	resolve := func() string {
+		t.Helper()
+		out, rep, err := Build(context.Background(), src, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rep.Warnings) > 0 {
+			t.Fatalf("warnings: %q", rep.Warnings)
+		}
+		return out.Apps[0].Versions[0].DownloadURL
+	}
+
+	if got, want := resolve(), srv.URL+"/v1.ipa"; got != want {
+		t.Fatalf("first run resolved to %s, want %s", got, want)
+	}
+	target.Store("/v2.ipa")
+	if got, want := resolve(), srv.URL+"/v2.ipa"; got != want {
+		t.Errorf("after the redirect moved, resolved to %s, want %s", got, want)
+	}
+}
+
+func TestDedupScreenshotContent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a.png", "/a-copy.png", "/a-copy2.png":
+			w.Write([]byte("same"))
+		default:
+			w.Write([]byte(r.URL.Path))
+		}
+	}))
+	defer srv.Close()
+	u := func(p string) string { return srv.URL + "/" + p }
+
+	out := &Root{Apps: []App{
+		{Name: "A", ScreenshotURLs: []string{u("a.png"), u("b.png"), u("a-copy.png")}},
+		{Name: "B", ScreenshotURLs: []string{u("a-copy.png"), u("c.png"), u("c.png")}},
+		{Name: "C", ScreenshotURLs: []string{u("d.png"), u("d.png")}},
+		{Name: "D", ScreenshotURLs: []string{u("a-copy.png"), u("a-copy2.png")}},
+	}}
+	rep := &Report{}
+	dedupScreenshotContent(context.Background(), srv.Client(), out, Options{Concurrency: 2}, rep)
+
+	want := [][]string{
+		{u("a.png"), u("b.png")},             // rewritten into one it already has
+		{u("a.png"), u("c.png"), u("c.png")}, // rewritten; the input's own repeat is kept
+		{u("d.png"), u("d.png")},             // nothing rewritten
+		{u("a.png")},                         // two rewritten to the same URL
+	}
+	for i, app := range out.Apps {
+		if !reflect.DeepEqual(app.ScreenshotURLs, want[i]) {
+			t.Errorf("app %s: screenshots %q, want %q", app.Name, app.ScreenshotURLs, want[i])
+		}
+	}
+	if len(rep.Warnings) != 2 {
+		t.Errorf("warnings = %q, want one per rewritten URL", rep.Warnings)
+	}
+}
+
+func TestCheckURLsNotCached(t *testing.T) {
+	var dead atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if dead.Load() {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("PK"))
+	}))
+	defer srv.Close()
+
+	src := []byte(`{"apps": [{"name": "A", "bundleIdentifier": "com.a", "screenshotURLs": ["https://example.com/1.png"],
+		"versions": [{"version": "1", "downloadURL": "` + srv.URL + `/a.ipa"}]}]}`)
+	opts := Options{CheckURLs: true, CacheDir: t.TempDir()}
+	check := func() []string {
+		t.Helper()
+		_, rep, err := Build(context.Background(), src, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rep.Warnings
+	}
+
+	if w := check(); len(w) > 0 {
+		t.Fatalf("first run warned: %q", w)
+	}
+	dead.Store(true)
+	if w := check(); len(w) != 1 || !strings.Contains(w[0], "HTTP 404") {
+		t.Errorf("after the link died, warnings = %q, want one HTTP 404", w)
+	}
+}
@@ -0,0 +1,18 @@
+package riperepo
+
+// reportNulls re-reads the JSON input b and warns about every known field
+// written as an explicit null, by path, e.g. apps[2].versions[0].downloadURL.
+// The decoder reads null the same as a missing key; this tells a field that
+// was cleared on purpose from one that was never set. Unknown keys are left
+// to -strict. NDJSON input is read line by line. It returns the number of
+// nulls found.
+func reportNulls(b []byte, ndjson bool, rep *Report) int {
+	n := 0
+	walkSchema(b, ndjson, func(path string, v interface{}, known bool) {
+		if known && v == nil {
+			rep.Warnf("%s is explicitly null", path)
+			n++
+		}
+	})
+	return n
+}
@@ -0,0 +1,739 @@
+package riperepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// dedupNews drops news items whose non-empty identifier was already seen,
+// keeping the first. Duplicates would show twice and re-notify for
+// notify:true items. Items without an identifier are always kept.
+func dedupNews(news []NewsItem, rep *Report) []NewsItem {
+	seen := make(map[string]bool, len(news))
+	kept := news[:0]
+	for _, n := range news {
+		if n.Identifier != "" {
+			if seen[n.Identifier] {
+				rep.Warnf("news %q: duplicate identifier, dropped %q", n.Identifier, n.Title)
+				continue
+			}
+			seen[n.Identifier] = true
+		}
+		kept = append(kept, n)
+	}
+	return kept
+}
+
+// sortNews orders news newest first by normalized date. Items whose date is
+// empty or unparseable go last, keeping their relative order.
+func sortNews(news []NewsItem) {
+	sort.SliceStable(news, func(i, j int) bool {
+		ti, tj := ParseFlexibleTime(news[i].Date), ParseFlexibleTime(news[j].Date)
+		if ti.IsZero() || tj.IsZero() {
+			return !ti.IsZero() && tj.IsZero()
+		}
+		return ti.After(tj)
+	})
+}
+
+// pruneOldNews drops news items dated more than maxAge before now. Items
+// whose date doesn't parse can't be judged, so they are kept with a warning;
+// undated items are kept silently. It returns the kept items and how many
+// were dropped.
+func pruneOldNews(news []NewsItem, maxAge time.Duration, now time.Time, rep *Report) ([]NewsItem, int) {
+	cutoff := now.Add(-maxAge)
+	kept := news[:0]
+	for _, n := range news {
+		if n.Date != "" {
+			t := ParseFlexibleTime(n.Date)
+			if t.IsZero() {
+				rep.Warnf("news %q: date %q doesn't parse; kept despite -prune-news-older-than", n.Identifier, n.Date)
+			} else if t.Before(cutoff) {
+				logv.Debugf("news %q: dated %s, pruned", n.Identifier, n.Date)
+				continue
+			}
+		}
+		kept = append(kept, n)
+	}
+	return kept, len(news) - len(kept)
+}
+
+// sortClientOrder orders apps the way the client lists them: featured apps
+// first, in featuredApps order, then the rest by category and name
+// (case-insensitively, uncategorized last). Apps that tie keep their order.
+func sortClientOrder(out *Root) {
+	rank := make(map[string]int, len(out.FeaturedApps))
+	for i, id := range out.FeaturedApps {
+		if _, ok := rank[id]; !ok {
+			rank[id] = i
+		}
+	}
+	sort.SliceStable(out.Apps, func(i, j int) bool {
+		a, b := out.Apps[i], out.Apps[j]
+		ra, fa := rank[a.BundleIdentifier]
+		rb, fb := rank[b.BundleIdentifier]
+		if fa || fb {
+			return fa && (!fb || ra < rb)
+		}
+		if ca, cb := strings.ToLower(a.Category), strings.ToLower(b.Category); ca != cb {
+			if ca == "" || cb == "" {
+				return cb == ""
+			}
+			return ca < cb
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+}
+
+// checkNewsAppIDs warns about news items whose appID names no app in the
+// source; tapping "open app" on those does nothing.
+func checkNewsAppIDs(out Root, rep *Report) {
+	ids := make(map[string]bool, len(out.Apps))
+	for _, app := range out.Apps {
+		ids[app.BundleIdentifier] = true
+	}
+	for _, n := range out.News {
+		id, ok := appIDString(n.AppID)
+		if !ok || ids[id] {
+			continue
+		}
+		rep.Warnf("news %q: appID %q matches no app's bundleIdentifier", n.Identifier, id)
+	}
+}
+
+// resolveNewsAppIDs rewrites news appIDs that aren't a bundle identifier to
+// the bundleIdentifier of the app they mean: a number (or numeric string) is
+// a 0-based index into apps, anything else is matched against app names,
+// ignoring case and punctuation, so a slug like "my-app" finds "My App".
+// Values that match more than one app by name are kept with a warning; ones
+// that match nothing are kept for checkNewsAppIDs to report. It returns how
+// many appIDs changed. apps must be in input order, before any filtering,
+// for the indexes to mean what the author meant.
+func resolveNewsAppIDs(out *Root, rep *Report) int {
+	ids := bundleIDSet(out.Apps)
+	byName := map[string][]string{}
+	for _, app := range out.Apps {
+		if k := nameKey(app.Name); k != "" && app.BundleIdentifier != "" {
+			byName[k] = append(byName[k], app.BundleIdentifier)
+		}
+	}
+	n := 0
+	for i := range out.News {
+		item := &out.News[i]
+		id, ok := appIDString(item.AppID)
+		if !ok || ids[id] {
+			continue
+		}
+		resolved := ""
+		if idx, err := strconv.Atoi(id); err == nil {
+			if idx >= 0 && idx < len(out.Apps) {
+				resolved = out.Apps[idx].BundleIdentifier
+			}
+		} else if matches := byName[nameKey(id)]; len(matches) == 1 {
+			resolved = matches[0]
+		} else if len(matches) > 1 {
+			rep.Warnf("news %q: appID %q matches several apps by name (%s); left as is", item.Identifier, id, strings.Join(matches, ", "))
+			continue
+		}
+		if resolved == "" {
+			// checkNewsAppIDs warns about it
+			continue
+		}
+		logv.Debugf("news %q: appID %q resolved to %s", item.Identifier, id, resolved)
+		item.AppID = resolved
+		n++
+	}
+	return n
+}
+
+// nameKey folds an app name or slug to lowercase letters and digits.
+func nameKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// appIDString renders a news appID as a string. Strings and numbers are
+// both accepted; null, empty and other shapes report ok=false.
+func appIDString(v interface{}) (string, bool) {
+	switch t := v.(type) {
+	case string:
+		return t, t != ""
+	case json.Number:
+		return t.String(), true
+	case float64:
+		return fmt.Sprintf("%v", t), true
+	}
+	return "", false
+}
+
+// filterCategories keeps apps whose normalized category is in include (when
+// include is non-empty) and then drops those whose category is in exclude.
+// An app without a category fails any include filter but only matches an
+// exclude filter that explicitly lists the empty category.
+func filterCategories(apps []App, include, exclude []string) []App {
+	inc, exc := categorySet(include), categorySet(exclude)
+	kept := apps[:0]
+	for _, app := range apps {
+		c := normalizeCategory(app.Category)
+		if len(inc) > 0 && !inc[c] {
+			continue
+		}
+		if exc[c] {
+			continue
+		}
+		kept = append(kept, app)
+	}
+	return kept
+}
+
+func categorySet(cats []string) map[string]bool {
+	set := make(map[string]bool, len(cats))
+	for _, c := range cats {
+		set[normalizeCategory(c)] = true
+	}
+	return set
+}
+
+// normalizeCategory folds case and surrounding space for category matching.
+func normalizeCategory(c string) string {
+	return strings.ToLower(strings.TrimSpace(c))
+}
+
+// filterBundleIDs keeps apps whose bundleIdentifier is in include (when
+// include is non-empty) and then drops those in exclude. Matching is exact.
+func filterBundleIDs(apps []App, include, exclude []string) []App {
+	inc, exc := stringSet(include), stringSet(exclude)
+	kept := apps[:0]
+	for _, app := range apps {
+		if len(inc) > 0 && !inc[app.BundleIdentifier] {
+			continue
+		}
+		if exc[app.BundleIdentifier] {
+			continue
+		}
+		kept = append(kept, app)
+	}
+	return kept
+}
+
+// dropBeta removes apps marked beta and, from the rest, versions marked
+// beta. It returns how many versions were removed from kept apps.
+func dropBeta(out *Root) int {
+	n := 0
+	kept := out.Apps[:0]
+	for _, app := range out.Apps {
+		if app.Beta {
+			logv.Debugf("app %q: beta, dropped", app.Name)
+			continue
+		}
+		versions := app.Versions[:0]
+		for _, v := range app.Versions {
+			if v.Beta {
+				logv.Debugf("app %q version %q: beta, dropped", app.Name, v.Version)
+				n++
+				continue
+			}
+			versions = append(versions, v)
+		}
+		app.Versions = versions
+		kept = append(kept, app)
+	}
+	out.Apps = kept
+	return n
+}
+
+// isPatreonGated reports whether an app's or version's patreon value (which
+// is never written out) restricts it to patrons: true, or an object such as
+// AltStore's {"pledge", "currency", "benefit", "tiers"}. An object whose
+// pledge is 0 and that lists no tiers is free, as are false, null and {}.
+func isPatreonGated(v interface{}) bool {
+	switch t := v.(type) {
+	case bool:
+		return t
+	case map[string]interface{}:
+		if tiers, ok := t["tiers"].([]interface{}); ok && len(tiers) > 0 {
+			return true
+		}
+		switch p := t["pledge"].(type) {
+		case json.Number:
+			f, err := p.Float64()
+			return err != nil || f > 0
+		case float64:
+			return p > 0
+		case nil:
+			// no pledge: gated if it says anything at all
+			return len(t) > 0
+		}
+		return true
+	}
+	return false
+}
+
+// dropPatreonGated removes patron-only apps and, from the rest, patron-only
+// versions. Apps left with no versions stay for -prune-empty-apps to decide,
+// as with -drop-beta. It returns how many apps and versions were removed.
+func dropPatreonGated(out *Root) (apps, versions int) {
+	kept := out.Apps[:0]
+	for _, app := range out.Apps {
+		if app.patreonGated {
+			logv.Debugf("app %q: patron-only, dropped", app.Name)
+			apps++
+			continue
+		}
+		vs := app.Versions[:0]
+		for _, v := range app.Versions {
+			if v.patreonGated {
+				logv.Debugf("app %q version %q: patron-only, dropped", app.Name, v.Version)
+				versions++
+				continue
+			}
+			vs = append(vs, v)
+		}
+		app.Versions = vs
+		kept = append(kept, app)
+	}
+	out.Apps = kept
+	return apps, versions
+}
+
+// checkMissingDownloads warns about versions without a downloadURL, which
+// clients show as an update that can't be installed. With drop they are
+// removed as well. It returns how many versions were dropped.
+func checkMissingDownloads(apps []App, drop bool, rep *Report) int {
+	n := 0
+	for i := range apps {
+		app := &apps[i]
+		kept := app.Versions[:0]
+		for _, v := range app.Versions {
+			if strings.TrimSpace(v.DownloadURL) == "" {
+				if drop {
+					rep.Warnf("app %q version %q: no downloadURL; dropped", app.Name, v.Version)
+					n++
+					continue
+				}
+				rep.Warnf("app %q version %q: no downloadURL", app.Name, v.Version)
+			}
+			kept = append(kept, v)
+		}
+		app.Versions = kept
+	}
+	return n
+}
+
+// pruneEmptyApps removes apps without any versions, which nothing can
+// install. It returns how many were removed.
+func pruneEmptyApps(out *Root) int {
+	kept := out.Apps[:0]
+	for _, app := range out.Apps {
+		if len(app.Versions) == 0 {
+			logv.Debugf("app %q: no versions, pruned", app.Name)
+			continue
+		}
+		kept = append(kept, app)
+	}
+	n := len(out.Apps) - len(kept)
+	out.Apps = kept
+	return n
+}
+
+// Plausible IPA sizes; -assume-size-unit warns outside this range.
+const (
+	minPlausibleSize = 10 << 10
+	maxPlausibleSize = 16 << 30
+)
+
+// sizeUnitMultiplier returns how many bytes one -assume-size-unit unit is.
+func sizeUnitMultiplier(unit string) (int64, error) {
+	switch strings.ToLower(unit) {
+	case "bytes":
+		return 1, nil
+	case "kb":
+		return 1 << 10, nil
+	case "mb":
+		return 1 << 20, nil
+	}
+	return 0, fmt.Errorf("unknown -assume-size-unit %q (want bytes, kb or mb)", unit)
+}
+
+// assumeSizeUnit rescales sizes that the input gave as plain numbers, read
+// as bytes by default, to unit: bytes, kb or mb (binary, as parseSize reads
+// them). Sizes written as strings with a unit are left alone. Every rescaled
+// size outside 10 KB-16 GB gets a warning, since that usually means the unit
+// is wrong.
+func assumeSizeUnit(apps []App, unit string, rep *Report) error {
+	mult, err := sizeUnitMultiplier(unit)
+	if err != nil {
+		return err
+	}
+	for i := range apps {
+		for j := range apps[i].Versions {
+			v := &apps[i].Versions[j]
+			if v.bareSize == 0 {
+				continue
+			}
+			if mult > 1 {
+				v.Size = int64(v.bareSize * float64(mult))
+			}
+			if v.Size < minPlausibleSize || v.Size > maxPlausibleSize {
+				rep.Warnf("app %q version %q: size %v read as %s is implausible for an IPA; check -assume-size-unit",
+					apps[i].Name, v.Version, v.bareSize, HumanizeSize(v.Size, false))
+			}
+		}
+	}
+	return nil
+}
+
+// fillVersionDescriptions gives versions without release notes the app's
+// description, or its subtitle when that's empty too, so the update screen
+// isn't blank. Versions with notes are untouched. It returns how many were
+// filled.
+func fillVersionDescriptions(apps []App) int {
+	n := 0
+	for i := range apps {
+		fallback := strings.TrimSpace(apps[i].LocalizedDescription)
+		if fallback == "" {
+			fallback = strings.TrimSpace(apps[i].Subtitle)
+		}
+		if fallback == "" {
+			continue
+		}
+		for j := range apps[i].Versions {
+			if v := &apps[i].Versions[j]; strings.TrimSpace(v.LocalizedDescription) == "" {
+				v.LocalizedDescription = fallback
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// appFieldIndex maps each App JSON field name to its struct field index.
+var appFieldIndex = func() map[string]int {
+	t := reflect.TypeOf(App{})
+	m := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+			m[name] = i
+		}
+	}
+	return m
+}()
+
+// requireFields checks every app for a non-empty value in each of fields,
+// given by JSON name. With mode "drop" incomplete apps are removed, with
+// "warn" they are kept; either way each gets a warning naming what's missing.
+func requireFields(apps []App, fields []string, mode string, rep *Report) ([]App, error) {
+	if err := checkRequireFields(fields, mode); err != nil {
+		return apps, err
+	}
+	idx := make([]int, 0, len(fields))
+	for _, f := range fields {
+		idx = append(idx, appFieldIndex[f])
+	}
+	kept := apps[:0]
+	for _, app := range apps {
+		v := reflect.ValueOf(app)
+		var missing []string
+		for n, i := range idx {
+			if f := v.Field(i); f.IsZero() || (f.Kind() == reflect.Slice || f.Kind() == reflect.Map) && f.Len() == 0 {
+				missing = append(missing, fields[n])
+			}
+		}
+		if len(missing) > 0 {
+			rep.IncompleteApps++
+			if mode == "drop" {
+				rep.Warnf("app %q (%s): missing required %s; dropped", app.Name, app.BundleIdentifier, strings.Join(missing, ", "))
+				continue
+			}
+			rep.Warnf("app %q (%s): missing required %s", app.Name, app.BundleIdentifier, strings.Join(missing, ", "))
+		}
+		kept = append(kept, app)
+	}
+	return kept, nil
+}
+
+// checkRequireFields reports an unknown -require-mode or -require field. An
+// empty mode is allowed and means warn.
+func checkRequireFields(fields []string, mode string) error {
+	if mode != "" && mode != "drop" && mode != "warn" {
+		return fmt.Errorf("unknown -require-mode %q (want drop or warn)", mode)
+	}
+	for _, f := range fields {
+		if _, ok := appFieldIndex[f]; !ok {
+			return fmt.Errorf("unknown -require field %q", f)
+		}
+	}
+	return nil
+}
+
+// pruneFeatured drops featuredApps entries for apps that were in had but are
+// no longer in out.Apps, so filtering never leaves a dangling feature.
+func pruneFeatured(out *Root, had map[string]bool) {
+	have := bundleIDSet(out.Apps)
+	kept := out.FeaturedApps[:0]
+	for _, id := range out.FeaturedApps {
+		if had[id] && !have[id] {
+			logv.Debugf("featured app %q pruned with its app", id)
+			continue
+		}
+		kept = append(kept, id)
+	}
+	out.FeaturedApps = kept
+}
+
+// dedupFeatured drops repeated featuredApps entries, keeping the first of
+// each, so the client's featured carousel doesn't show an app twice. It
+// returns how many were dropped.
+func dedupFeatured(ids []string) ([]string, int) {
+	seen := make(map[string]bool, len(ids))
+	kept := ids[:0]
+	for _, id := range ids {
+		if seen[id] {
+			logv.Debugf("featured app %q: repeated, dropped", id)
+			continue
+		}
+		seen[id] = true
+		kept = append(kept, id)
+	}
+	return kept, len(ids) - len(kept)
+}
+
+func bundleIDSet(apps []App) map[string]bool {
+	set := make(map[string]bool, len(apps))
+	for _, app := range apps {
+		set[app.BundleIdentifier] = true
+	}
+	return set
+}
+
+func stringSet(items []string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, s := range items {
+		set[s] = true
+	}
+	return set
+}
+
+// limitScreenshots keeps at most max screenshots per app; max <= 0 keeps all.
+func limitScreenshots(apps []App, max int) {
+	if max <= 0 {
+		return
+	}
+	for i := range apps {
+		if n := len(apps[i].ScreenshotURLs); n > max {
+			apps[i].ScreenshotURLs = apps[i].ScreenshotURLs[:max]
+			logv.Debugf("app %q: trimmed %d of %d screenshots", apps[i].Name, n-max, n)
+		}
+	}
+}
+
+// rewriteHosts moves the source icon and each app's icon, screenshot and
+// download URLs whose host exactly matches a key in hosts onto the mapped
+// host. It returns how many URLs changed.
+func rewriteHosts(out *Root, hosts map[string]string) int {
+	n := 0
+	rewrite := func(s *string) {
+		if *s == "" {
+			return
+		}
+		u, err := url.Parse(*s)
+		if err != nil || u.Host == "" {
+			return
+		}
+		repl, ok := hosts[strings.ToLower(u.Host)]
+		if !ok {
+			return
+		}
+		u.Host = repl
+		*s = u.String()
+		n++
+	}
+	rewrite(&out.IconURL)
+	for i := range out.Apps {
+		app := &out.Apps[i]
+		rewrite(&app.IconURL)
+		for j := range app.ScreenshotURLs {
+			rewrite(&app.ScreenshotURLs[j])
+		}
+		for j := range app.Versions {
+			rewrite(&app.Versions[j].DownloadURL)
+		}
+	}
+	return n
+}
+
+// encodeURLs runs normalizeURL over every URL field in out: the source's
+// sourceURL, icon, header, website and Patreon links, each app's icon,
+// screenshots, video and downloads, and each news item's image and link. It
+// returns how many URLs changed.
+func encodeURLs(out *Root) int {
+	n := 0
+	encode := func(s *string) {
+		if u := normalizeURL(*s); u != *s {
+			logv.Debugf("encode-urls: %s -> %s", *s, u)
+			*s = u
+			n++
+		}
+	}
+	for _, s := range []*string{&out.SourceURL, &out.IconURL, &out.HeaderURL, &out.Website, &out.PatreonURL} {
+		encode(s)
+	}
+	for i := range out.Apps {
+		app := &out.Apps[i]
+		encode(&app.IconURL)
+		encode(&app.VideoURL)
+		for j := range app.ScreenshotURLs {
+			encode(&app.ScreenshotURLs[j])
+		}
+		for j := range app.Versions {
+			encode(&app.Versions[j].DownloadURL)
+		}
+	}
+	for i := range out.News {
+		encode(&out.News[i].ImageURL)
+		encode(&out.News[i].URL)
+	}
+	return n
+}
+
+// normalizeURL percent-encodes spaces and other characters that aren't
+// allowed in the path, query or fragment of an http(s) URL, e.g.
+// ".../My App.ipa" becomes ".../My%20App.ipa". Existing escapes are kept, so
+// it is idempotent. Other schemes (data: icons) and unparseable values are
+// returned unchanged.
+func normalizeURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return s
+	}
+	// url keeps RawQuery verbatim, so escape it here, leaving the query's
+	// own syntax (&, =, existing %XX) alone
+	var q strings.Builder
+	for i := 0; i < len(u.RawQuery); i++ {
+		c := u.RawQuery[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte("\"<>\\^`{|}", c) >= 0 {
+			fmt.Fprintf(&q, "%%%02X", c)
+			continue
+		}
+		q.WriteByte(c)
+	}
+	u.RawQuery = q.String()
+	return u.String()
+}
+
+// ipaExtensions are the download file extensions clients can install.
+var ipaExtensions = []string{".ipa", ".tipa"}
+
+// hasIPAExtension reports whether path ends in one of ipaExtensions, in any
+// case.
+func hasIPAExtension(path string) bool {
+	path = strings.ToLower(path)
+	for _, ext := range ipaExtensions {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDownloadExtensions warns about downloadURLs whose path doesn't end in
+// an IPA extension; those usually point at a release page instead of the
+// file. URLs starting with one of allow (extensionless API asset links) are
+// skipped.
+func checkDownloadExtensions(apps []App, allow []string, rep *Report) {
+	for _, app := range apps {
+	versions:
+		for _, v := range app.Versions {
+			if v.DownloadURL == "" {
+				continue
+			}
+			for _, p := range allow {
+				if p != "" && strings.HasPrefix(v.DownloadURL, p) {
+					continue versions
+				}
+			}
+			path := v.DownloadURL
+			if u, err := url.Parse(v.DownloadURL); err == nil {
+				path = u.Path
+			}
+			if !hasIPAExtension(path) {
+				rep.Warnf("app %q version %q: downloadURL %q does not end in .ipa or .tipa", app.Name, v.Version, v.DownloadURL)
+			}
+		}
+	}
+}
+
+// checkDuplicateDownloads warns when versions of one app share a downloadURL,
+// usually a copy-paste slip where the notes were updated but the IPA wasn't.
+// Nothing is removed since it's sometimes intentional.
+func checkDuplicateDownloads(apps []App, rep *Report) {
+	for _, app := range apps {
+		byURL := map[string][]string{}
+		var order []string
+		for _, v := range app.Versions {
+			if v.DownloadURL == "" {
+				continue
+			}
+			if _, ok := byURL[v.DownloadURL]; !ok {
+				order = append(order, v.DownloadURL)
+			}
+			byURL[v.DownloadURL] = append(byURL[v.DownloadURL], v.Version)
+		}
+		for _, u := range order {
+			if vs := byURL[u]; len(vs) > 1 {
+				rep.DuplicateDownloads++
+				rep.Warnf("app %q: versions %s share downloadURL %q", app.Name, strings.Join(vs, ", "), u)
+			}
+		}
+	}
+}
+
+// checkScreenshots warns about apps with no screenshots; their store pages
+// look empty. Screenshot objects have been flattened into ScreenshotURLs by
+// now, so that's the only field to look at.
+func checkScreenshots(apps []App, rep *Report) {
+	for _, app := range apps {
+		if len(app.ScreenshotURLs) == 0 {
+			rep.NoScreenshotApps++
+			rep.Warnf("app %q: no screenshots", app.Name)
+		}
+	}
+}
+
+// checkSharedScreenshots warns when one screenshot URL is used by more than
+// one app, which usually means an app was copied from another and kept its
+// screenshots. Apps are named by bundle identifier, or by name if they have
+// none. Only a heuristic, so it's opt-in.
+func checkSharedScreenshots(apps []App, rep *Report) {
+	byURL := map[string][]string{}
+	var order []string
+	for _, app := range apps {
+		id := defaultIfEmpty(app.BundleIdentifier, app.Name)
+		for _, u := range app.ScreenshotURLs {
+			ids := byURL[u]
+			if len(ids) == 0 {
+				order = append(order, u)
+			} else if ids[len(ids)-1] == id {
+				// repeated within one app; not this check's concern
+				continue
+			}
+			byURL[u] = append(ids, id)
+		}
+	}
+	for _, u := range order {
+		if ids := byURL[u]; len(ids) > 1 {
+			rep.Warnf("screenshot %q is used by apps %s", u, strings.Join(ids, ", "))
+		}
+	}
+}
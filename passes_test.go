@@ -0,0 +1,352 @@
+package riperepo
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSortNews(t *testing.T) {
+	news := []NewsItem{
+		{Title: "undated"},
+		{Title: "old", Date: "2023-01-01T00:00:00Z"},
+		{Title: "garbled", Date: "last tuesday"},
+		{Title: "new", Date: "2024-06-01T00:00:00Z"},
+		{Title: "mid", Date: "2023-09-15"},
+		{Title: "undated 2", Date: ""},
+	}
+	sortNews(news)
+	var got []string
+	for _, n := range news {
+		got = append(got, n.Title)
+	}
+	// dated newest first; the rest last, in their original order
+	want := []string{"new", "mid", "old", "undated", "garbled", "undated 2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %q, want %q", got, want)
+	}
+}
+
+func appIDs(apps []App) []string {
+	ids := []string{}
+	for _, app := range apps {
+		ids = append(ids, app.BundleIdentifier)
+	}
+	return ids
+}
+
+func TestFilterBundleIDs(t *testing.T) {
+	mk := func() []App {
+		return []App{{BundleIdentifier: "com.a"}, {BundleIdentifier: "com.b"}, {BundleIdentifier: "com.c"}}
+	}
+	tests := []struct {
+		name             string
+		include, exclude []string
+		want             []string
+	}{
+		{"include only", []string{"com.c", "com.a", "com.missing"}, nil, []string{"com.a", "com.c"}},
+		{"exclude only", nil, []string{"com.b"}, []string{"com.a", "com.c"}},
+		{"exclude trims include", []string{"com.a", "com.b"}, []string{"com.a"}, []string{"com.b"}},
+		{"neither", nil, nil, []string{"com.a", "com.b", "com.c"}},
+	}
+	for _, tt := range tests {
+		if got := appIDs(filterBundleIDs(mk(), tt.include, tt.exclude)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: kept %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFilterBundleIDsPrunesFeatured(t *testing.T) {
+	src := `{"featuredApps": ["com.b", "com.a", "com.elsewhere"], "apps": [
+		{"name": "A", "bundleIdentifier": "com.a"}, {"name": "B", "bundleIdentifier": "com.b"}]}`
+	out, _, err := Build(context.Background(), []byte(src), Options{ExcludeBundleIDs: []string{"com.b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// com.elsewhere was never in this source, so it isn't ours to prune
+	if want := []string{"com.a", "com.elsewhere"}; !reflect.DeepEqual(out.FeaturedApps, want) {
+		t.Errorf("featuredApps = %q, want %q", out.FeaturedApps, want)
+	}
+}
+
+func TestDropBeta(t *testing.T) {
+	src := `{"apps": [
+		{"name": "Stable", "bundleIdentifier": "com.stable", "versions": [
+			{"version": "2.0b1", "beta": true, "downloadURL": "https://example.com/s-2.0b1.ipa"},
+			{"version": "1.0", "downloadURL": "https://example.com/s-1.0.ipa"}]},
+		{"name": "Preview", "bundleIdentifier": "com.preview", "beta": true, "versions": [
+			{"version": "0.1", "downloadURL": "https://example.com/p.ipa"}]}
+	]}`
+
+	// off: the flags are kept for clients that show betas themselves
+	out, _, err := Build(context.Background(), []byte(src), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Apps) != 2 || !out.Apps[1].Beta || !out.Apps[0].Versions[0].Beta || out.Apps[0].Versions[1].Beta {
+		t.Fatalf("beta flags not preserved: %+v", out.Apps)
+	}
+
+	out, _, err = Build(context.Background(), []byte(src), Options{DropBeta: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := appIDs(out.Apps); !reflect.DeepEqual(got, []string{"com.stable"}) {
+		t.Errorf("apps = %q, want the beta app dropped", got)
+	}
+	if v := out.Apps[0].Versions; len(v) != 1 || v[0].Version != "1.0" {
+		t.Errorf("versions = %+v, want only 1.0", v)
+	}
+}
+
+func TestSortClientOrder(t *testing.T) {
+	out := Root{
+		FeaturedApps: []string{"com.feat2", "com.feat1", "com.feat2"},
+		Apps: []App{
+			{BundleIdentifier: "com.zeta", Name: "zeta", Category: "utilities"},
+			{BundleIdentifier: "com.feat1", Name: "Feat One", Category: "games"},
+			{BundleIdentifier: "com.none", Name: "No Category"},
+			{BundleIdentifier: "com.alpha", Name: "Alpha", Category: "Utilities"},
+			{BundleIdentifier: "com.game", Name: "Game", Category: "games"},
+			{BundleIdentifier: "com.feat2", Name: "Feat Two"},
+		},
+	}
+	sortClientOrder(&out)
+	// featured in featuredApps order; then by category, case-insensitively,
+	// with uncategorized last; then by name
+	want := []string{"com.feat2", "com.feat1", "com.game", "com.alpha", "com.zeta", "com.none"}
+	if got := appIDs(out.Apps); !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %q, want %q", got, want)
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"https://example.com/My App.ipa", "https://example.com/My%20App.ipa"},
+		{"https://example.com/dl/My App v2 (final).ipa", "https://example.com/dl/My%20App%20v2%20%28final%29.ipa"},
+		{"https://example.com/a.ipa?name=My App&v=1", "https://example.com/a.ipa?name=My%20App&v=1"},
+		{"https://example.com/a b.png#sec tion", "https://example.com/a%20b.png#sec%20tion"},
+		{"https://example.com/café.ipa", "https://example.com/caf%C3%A9.ipa"},
+		// already encoded: left as is
+		{"https://example.com/My%20App.ipa", "https://example.com/My%20App.ipa"},
+		{"https://example.com/a%2Fb.ipa?q=a%26b", "https://example.com/a%2Fb.ipa?q=a%26b"},
+		{"https://example.com/plain.ipa", "https://example.com/plain.ipa"},
+		// other schemes and unparseable values: unchanged
+		{"data:image/png;base64,iVBOR w0KGgo=", "data:image/png;base64,iVBOR w0KGgo="},
+		{"not a url", "not a url"},
+		{"https://exa mple.com/a.ipa", "https://exa mple.com/a.ipa"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got := normalizeURL(tt.in)
+		if got != tt.want {
+			t.Errorf("normalizeURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if again := normalizeURL(got); again != got {
+			t.Errorf("normalizeURL not idempotent on %q: got %q", got, again)
+		}
+	}
+}
+
+func TestDropPatreonGated(t *testing.T) {
+	src := `{"apps": [
+		{"name": "Free", "bundleIdentifier": "com.free", "versions": [
+			{"version": "2", "patreon": {"pledge": 5, "currency": "USD"}},
+			{"version": "1", "patreon": {"pledge": 0}}]},
+		{"name": "Members", "bundleIdentifier": "com.members", "patreon": true, "versions": [{"version": "1"}]},
+		{"name": "Early", "bundleIdentifier": "com.early", "versions": [{"version": "1", "patreon": {"tiers": ["gold"]}}]}
+	]}`
+	out, _, err := Build(context.Background(), []byte(src), Options{DropPatreonGated: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the emptied app stays; -prune-empty-apps decides
+	if got, want := appIDs(out.Apps), []string{"com.free", "com.early"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("apps = %q, want %q", got, want)
+	}
+	if v := out.Apps[0].Versions; len(v) != 1 || v[0].Version != "1" {
+		t.Errorf("com.free versions = %+v, want only the free 1", v)
+	}
+
+	out, _, err = Build(context.Background(), []byte(src), Options{DropPatreonGated: true, PruneEmptyApps: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := appIDs(out.Apps), []string{"com.free"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("with PruneEmptyApps apps = %q, want %q", got, want)
+	}
+}
+
+func TestCheckMissingDownloads(t *testing.T) {
+	mk := func() []App {
+		return []App{
+			{Name: "Mixed", Versions: []Version{
+				{Version: "3", DownloadURL: "https://example.com/3.ipa"},
+				{Version: "2"},
+				{Version: "1.5", DownloadURL: "   "},
+				{Version: "1", DownloadURL: "https://example.com/1.ipa"},
+			}},
+			{Name: "Complete", Versions: []Version{{Version: "1", DownloadURL: "https://example.com/c.ipa"}}},
+			{Name: "Empty", Versions: []Version{{Version: "1"}}},
+		}
+	}
+	versions := func(app App) []string {
+		var vs []string
+		for _, v := range app.Versions {
+			vs = append(vs, v.Version)
+		}
+		return vs
+	}
+
+	// warn only: everything kept
+	apps, rep := mk(), &Report{}
+	if n := checkMissingDownloads(apps, false, rep); n != 0 {
+		t.Errorf("dropped %d without drop", n)
+	}
+	if got := versions(apps[0]); !reflect.DeepEqual(got, []string{"3", "2", "1.5", "1"}) {
+		t.Errorf("versions = %q, want all kept", got)
+	}
+	if len(rep.Warnings) != 3 {
+		t.Errorf("warnings = %q, want one per version without a downloadURL", rep.Warnings)
+	}
+
+	apps, rep = mk(), &Report{}
+	if n := checkMissingDownloads(apps, true, rep); n != 3 {
+		t.Errorf("dropped %d versions, want 3", n)
+	}
+	if got := versions(apps[0]); !reflect.DeepEqual(got, []string{"3", "1"}) {
+		t.Errorf("mixed versions = %q, want [3 1]", got)
+	}
+	if len(apps[1].Versions) != 1 || len(apps[2].Versions) != 0 {
+		t.Errorf("complete kept %d, empty kept %d; want 1, 0", len(apps[1].Versions), len(apps[2].Versions))
+	}
+	if len(rep.Warnings) != 3 {
+		t.Errorf("warnings = %q, want one per dropped version", rep.Warnings)
+	}
+}
+
+func TestAssumeSizeUnit(t *testing.T) {
+	src := `{"apps": [{"name": "A", "versions": [
+		{"version": "1", "size": 12},
+		{"version": "2", "size": 1.5},
+		{"version": "3", "size": "12 MB"},
+		{"version": "4", "size": 20000000},
+		{"version": "5"}
+	]}]}`
+	tests := []struct {
+		unit     string
+		want     []int64
+		warnings int
+	}{
+		// bytes: 12 and 1.5 (read as 1) are implausibly small
+		{"bytes", []int64{12, 1, 12 << 20, 20000000, 0}, 2},
+		{"kb", []int64{12 << 10, 1536, 12 << 20, 20000000 << 10, 0}, 2},
+		{"MB", []int64{12 << 20, 3 << 19, 12 << 20, 20000000 << 20, 0}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.unit, func(t *testing.T) {
+			out, rep, err := Build(context.Background(), []byte(src), Options{AssumeSizeUnit: tt.unit})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int64
+			for _, v := range out.Apps[0].Versions {
+				got = append(got, v.Size)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sizes = %v, want %v", got, tt.want)
+			}
+			var implausible int
+			for _, w := range rep.Warnings {
+				if strings.Contains(w, "implausible") {
+					implausible++
+				}
+			}
+			if implausible != tt.warnings {
+				t.Errorf("%d implausible-size warnings, want %d: %q", implausible, tt.warnings, rep.Warnings)
+			}
+		})
+	}
+
+	if _, _, err := Build(context.Background(), []byte(src), Options{AssumeSizeUnit: "gb"}); err == nil {
+		t.Error("unknown unit accepted")
+	}
+}
+
+func TestResolveNewsAppIDs(t *testing.T) {
+	out, _ := decodeString(t, `{
+		"apps": [
+			{"name": "My App", "bundleIdentifier": "com.example.myapp"},
+			{"name": "Other", "bundleIdentifier": "com.example.other"},
+			{"name": "Twin", "bundleIdentifier": "com.example.twin1"},
+			{"name": "twin!", "bundleIdentifier": "com.example.twin2"}
+		],
+		"news": [
+			{"identifier": "index", "appID": 1},
+			{"identifier": "index string", "appID": "0"},
+			{"identifier": "out of range", "appID": 9},
+			{"identifier": "negative", "appID": -1},
+			{"identifier": "name", "appID": "Other"},
+			{"identifier": "slug", "appID": "my-app"},
+			{"identifier": "ambiguous", "appID": "twin"},
+			{"identifier": "unknown", "appID": "nothing"},
+			{"identifier": "already", "appID": "com.example.other"},
+			{"identifier": "none"}
+		]
+	}`)
+	rep := &Report{}
+	if n := resolveNewsAppIDs(&out, rep); n != 4 {
+		t.Errorf("resolved %d appIDs, want 4", n)
+	}
+	want := map[string]interface{}{
+		"index":        "com.example.other",
+		"index string": "com.example.myapp",
+		"out of range": "9",
+		"negative":     "-1",
+		"name":         "com.example.other",
+		"slug":         "com.example.myapp",
+		"ambiguous":    "twin",
+		"unknown":      "nothing",
+		"already":      "com.example.other",
+		"none":         nil,
+	}
+	for _, n := range out.News {
+		got := n.AppID
+		if num, ok := got.(json.Number); ok {
+			got = num.String()
+		}
+		if got != want[n.Identifier] {
+			t.Errorf("news %q: appID = %v, want %v", n.Identifier, got, want[n.Identifier])
+		}
+	}
+	// unmatched values are left for checkNewsAppIDs, so only the ambiguous
+	// name warns here
+	if len(rep.Warnings) != 1 || !strings.Contains(rep.Warnings[0], "matches several apps") {
+		t.Errorf("warnings = %q", rep.Warnings)
+	}
+}
+
+func TestDedupFeatured(t *testing.T) {
+	tests := []struct {
+		in      []string
+		want    []string
+		dropped int
+	}{
+		{[]string{"a", "b", "a", "c", "b", "b", "d", "a"}, []string{"a", "b", "c", "d"}, 4},
+		{[]string{"c", "a", "b"}, []string{"c", "a", "b"}, 0},
+		{[]string{"x", "x", "x"}, []string{"x"}, 2},
+		{nil, nil, 0},
+	}
+	for _, tt := range tests {
+		in := append([]string(nil), tt.in...)
+		got, dropped := dedupFeatured(in)
+		if len(got) != len(tt.want) || len(got) > 0 && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("dedupFeatured(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if dropped != tt.dropped {
+			t.Errorf("dedupFeatured(%q) dropped %d, want %d", tt.in, dropped, tt.dropped)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package riperepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// canonicalPermissions is the shape -canonical-permissions writes
+// appPermissions in:
+//
+//	{"entitlements": ["com.apple.developer.…", …], "privacy": {"NS…UsageDescription": "…", …}}
+//
+// Entitlements are sorted and unique; privacy keys are sorted by encoding/json.
+// Either member is omitted when empty.
+type canonicalPermissions struct {
+	Entitlements []string          `json:"entitlements,omitempty"`
+	Privacy      map[string]string `json:"privacy,omitempty"`
+}
+
+// canonicalizePermissions rewrites each app's appPermissions in the
+// canonical shape. It accepts entitlements as an array of strings, an array
+// of {"name": …} objects or an object keyed by entitlement, and privacy as an
+// object of key → description, an array of {"name", "usageDescription"}
+// objects or an array of bare keys. Anything else leaves that app's
+// appPermissions as it was, with a warning. It returns how many apps changed.
+func canonicalizePermissions(apps []App, rep *Report) int {
+	n := 0
+	for i := range apps {
+		app := &apps[i]
+		if len(app.AppPermissions) == 0 {
+			continue
+		}
+		perms, err := parsePermissions(app.AppPermissions)
+		if err != nil {
+			rep.Warnf("app %q: appPermissions kept as is: %v", app.Name, err)
+			continue
+		}
+		b, err := json.Marshal(perms)
+		if err != nil {
+			rep.Warnf("app %q: appPermissions kept as is: %v", app.Name, err)
+			continue
+		}
+		if string(b) != string(app.AppPermissions) {
+			n++
+		}
+		app.AppPermissions = b
+	}
+	return n
+}
+
+func parsePermissions(raw json.RawMessage) (canonicalPermissions, error) {
+	var perms canonicalPermissions
+	var m map[string]interface{}
+	if err := unmarshalNumbers(raw, &m); err != nil {
+		return perms, fmt.Errorf("not an object")
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := m[k]
+		var err error
+		switch k {
+		case "entitlements":
+			perms.Entitlements, err = parseEntitlements(v)
+		case "privacy":
+			perms.Privacy, err = parsePrivacy(v)
+		default:
+			err = fmt.Errorf("unknown key %q", k)
+		}
+		if err != nil {
+			return perms, err
+		}
+	}
+	return perms, nil
+}
+
+func parseEntitlements(v interface{}) ([]string, error) {
+	seen := map[string]bool{}
+	add := func(s string) {
+		if s != "" {
+			seen[s] = true
+		}
+	}
+	switch t := v.(type) {
+	case nil:
+	case []interface{}:
+		for _, item := range t {
+			switch it := item.(type) {
+			case string:
+				add(it)
+			case map[string]interface{}:
+				name, ok := it["name"].(string)
+				if !ok {
+					return nil, fmt.Errorf("entitlement object without a name")
+				}
+				add(name)
+			default:
+				return nil, fmt.Errorf("entitlement of type %T", item)
+			}
+		}
+	case map[string]interface{}:
+		for k := range t {
+			add(k)
+		}
+	default:
+		return nil, fmt.Errorf("entitlements of type %T", v)
+	}
+	list := make([]string, 0, len(seen))
+	for s := range seen {
+		list = append(list, s)
+	}
+	sort.Strings(list)
+	return list, nil
+}
+
+func parsePrivacy(v interface{}) (map[string]string, error) {
+	privacy := map[string]string{}
+	switch t := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		for k, desc := range t {
+			s, ok := desc.(string)
+			if !ok && desc != nil {
+				return nil, fmt.Errorf("privacy %q: description of type %T", k, desc)
+			}
+			privacy[k] = s
+		}
+	case []interface{}:
+		for _, item := range t {
+			switch it := item.(type) {
+			case string:
+				privacy[it] = ""
+			case map[string]interface{}:
+				name, ok := it["name"].(string)
+				if !ok {
+					return nil, fmt.Errorf("privacy object without a name")
+				}
+				desc, _ := it["usageDescription"].(string)
+				privacy[name] = desc
+			default:
+				return nil, fmt.Errorf("privacy entry of type %T", item)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("privacy of type %T", v)
+	}
+	return privacy, nil
+}
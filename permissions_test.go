@@ -0,0 +1,67 @@
+package riperepo
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParsePermissions(t *testing.T) {
+	tests := []struct {
+		name, in, want string // want "" means an error
+	}{
+		{"canonical", `{"entitlements": ["b", "a"], "privacy": {"NSCameraUsageDescription": "Scan"}}`,
+			`{"entitlements":["a","b"],"privacy":{"NSCameraUsageDescription":"Scan"}}`},
+		{"entitlement objects", `{"entitlements": [{"name": "com.apple.security.app-sandbox"}, {"name": "keychain-access-groups"}]}`,
+			`{"entitlements":["com.apple.security.app-sandbox","keychain-access-groups"]}`},
+		{"entitlements keyed by name", `{"entitlements": {"get-task-allow": true, "application-identifier": "X.com.a"}}`,
+			`{"entitlements":["application-identifier","get-task-allow"]}`},
+		{"duplicate entitlements", `{"entitlements": ["a", {"name": "a"}, "", "b"]}`,
+			`{"entitlements":["a","b"]}`},
+		{"privacy objects", `{"privacy": [{"name": "NSPhotoLibraryUsageDescription", "usageDescription": "Save"}, {"name": "NSMicrophoneUsageDescription"}]}`,
+			`{"privacy":{"NSMicrophoneUsageDescription":"","NSPhotoLibraryUsageDescription":"Save"}}`},
+		{"privacy bare keys", `{"privacy": ["NSCameraUsageDescription"]}`,
+			`{"privacy":{"NSCameraUsageDescription":""}}`},
+		{"null members", `{"entitlements": null, "privacy": null}`, `{}`},
+		{"empty", `{}`, `{}`},
+		{"not an object", `["a"]`, ""},
+		{"unknown key", `{"entitlements": [], "extras": 1}`, ""},
+		{"entitlement number", `{"entitlements": [1]}`, ""},
+		{"privacy description number", `{"privacy": {"NSCameraUsageDescription": 3}}`, ""},
+		{"privacy object without name", `{"privacy": [{"usageDescription": "x"}]}`, ""},
+	}
+	for _, tt := range tests {
+		perms, err := parsePermissions(json.RawMessage(tt.in))
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s: parsed %+v, want an error", tt.name, perms)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got, _ := json.Marshal(perms); string(got) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalizePermissionsKeepsUnparseable(t *testing.T) {
+	apps := []App{
+		{Name: "Good", AppPermissions: json.RawMessage(`{"privacy": ["NSCameraUsageDescription"]}`)},
+		{Name: "Odd", AppPermissions: json.RawMessage(`{"extras": 1}`)},
+		{Name: "None"},
+	}
+	rep := &Report{}
+	if n := canonicalizePermissions(apps, rep); n != 1 {
+		t.Errorf("changed %d apps, want 1", n)
+	}
+	if got := string(apps[1].AppPermissions); got != `{"extras": 1}` {
+		t.Errorf("unparseable permissions rewritten to %s", got)
+	}
+	if len(rep.Warnings) != 1 || !strings.Contains(rep.Warnings[0], `app "Odd"`) {
+		t.Errorf("warnings = %q, want one about Odd", rep.Warnings)
+	}
+}
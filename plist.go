@@ -0,0 +1,70 @@
+package riperepo
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"howett.net/plist"
+)
+
+// looksLikePlist reports whether b starts like an XML property list.
+func looksLikePlist(b []byte) bool {
+	b = bytes.TrimSpace(b)
+	return bytes.HasPrefix(b, []byte("<?xml")) || bytes.HasPrefix(b, []byte("<plist")) ||
+		bytes.HasPrefix(b, []byte("<!DOCTYPE plist"))
+}
+
+// decodePlist parses a property-list source and normalizes it exactly like
+// JSON input, after mapping plist values onto what encoding/json produces.
+func decodePlist(b []byte, rep *Report) (Root, error) {
+	var v interface{}
+	if _, err := plist.Unmarshal(b, &v); err != nil {
+		return Root{}, err
+	}
+	switch top := plistToJSON(v).(type) {
+	case map[string]interface{}:
+		return rootFromMap(top, rep), nil
+	case []interface{}:
+		return rootFromArray(top, rep)
+	default:
+		return Root{}, fmt.Errorf("plist root is %s, expected a dict or array", jsonKind(top))
+	}
+}
+
+// plistToJSON converts decoded plist values to their encoding/json
+// equivalents: integers and reals become json.Number (as unmarshalNumbers
+// produces), dates RFC3339 strings and data base64 strings, so getStr and
+// friends see the usual shapes.
+func plistToJSON(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[k] = plistToJSON(e)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(t))
+		for i, e := range t {
+			a[i] = plistToJSON(e)
+		}
+		return a
+	case uint64:
+		return json.Number(strconv.FormatUint(t, 10))
+	case int64:
+		return json.Number(strconv.FormatInt(t, 10))
+	case float64:
+		return json.Number(strconv.FormatFloat(t, 'g', -1, 64))
+	case float32:
+		return json.Number(strconv.FormatFloat(float64(t), 'g', -1, 32))
+	case time.Time:
+		return t.UTC().Format(time.RFC3339)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(t)
+	}
+	return v
+}
@@ -0,0 +1,1317 @@
+// Package riperepo normalizes AltStore-style source documents: it decodes
+// loosely formatted JSON, NDJSON or plist sources, runs the passes selected
+// in Options and encodes the result with a fixed field order. The
+// altstudio-fix command in cmd/altstudio-fix is a thin CLI over it.
+package riperepo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// The identifier and sourceURL used for sources that have none, unless
+// Options or the environment of the CLI says otherwise.
+const (
+	HardcodedIdentifier = "com.ripestore.source"
+	HardcodedSourceURL  = "https://raw.githubusercontent.com/RipeStore/repos/main/RipeStore.json"
+)
+
+// ToolName identifies this tool in the CLI's -version, the User-Agent and
+// the generator stamp.
+const ToolName = "altstudio-fix"
+
+// Root has fields in the order we want them to appear in output JSON.
+type Root struct {
+	Name         string     `json:"name,omitempty"`
+	Subtitle     string     `json:"subtitle,omitempty"`
+	Identifier   string     `json:"identifier,omitempty"`
+	SourceURL    string     `json:"sourceURL,omitempty"`
+	Description  string     `json:"description,omitempty"`
+	IconURL      string     `json:"iconURL,omitempty"`
+	Website      string     `json:"website,omitempty"`
+	PatreonURL   string     `json:"patreonURL,omitempty"`
+	HeaderURL    string     `json:"headerURL,omitempty"`
+	TintColor    string     `json:"tintColor,omitempty"`
+	FeaturedApps []string   `json:"featuredApps,omitempty"`
+	Apps         []App      `json:"apps,omitempty"`
+	News         []NewsItem `json:"news,omitempty"`
+	// AppCount is only set with -emit-count; it comes last so the default
+	// output is unchanged.
+	AppCount int `json:"appCount,omitempty"`
+	// Generator is only set with -stamp-generator and comes after appCount,
+	// last of all, for the same reason.
+	Generator *Generator `json:"generator,omitempty"`
+}
+
+// Generator records which build of this tool wrote a source, and when.
+type Generator struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Generated string `json:"generated"`
+}
+
+type App struct {
+	Name                 string `json:"name,omitempty"`
+	BundleIdentifier     string `json:"bundleIdentifier,omitempty"`
+	DeveloperName        string `json:"developerName,omitempty"`
+	Subtitle             string `json:"subtitle,omitempty"`
+	LocalizedDescription string `json:"localizedDescription,omitempty"`
+	// Per-locale overrides of name, subtitle and localizedDescription, keyed
+	// by locale (e.g. "en", "de", "pt-BR"). Clients should use the entry for
+	// the user's exact locale, then its language alone ("pt" for "pt-BR"),
+	// then the plain field, which is the default and "en" text.
+	LocalizedNames        map[string]string `json:"localizedNames,omitempty"`
+	LocalizedSubtitles    map[string]string `json:"localizedSubtitles,omitempty"`
+	LocalizedDescriptions map[string]string `json:"localizedDescriptions,omitempty"`
+	IconURL               string            `json:"iconURL,omitempty"`
+	TintColor             string            `json:"tintColor,omitempty"`
+	Category              string            `json:"category,omitempty"`
+	Beta                  bool              `json:"beta,omitempty"`
+	ScreenshotURLs        []string          `json:"screenshotURLs,omitempty"`
+	VideoURL              string            `json:"videoURL,omitempty"`
+	Versions              []Version         `json:"versions,omitempty"`
+	AppPermissions        json.RawMessage   `json:"appPermissions,omitempty"`
+	// marketplaceID, patreon and buildVersion intentionally omitted
+
+	patreonGated bool // the input's patreon object makes it patron-only, see isPatreonGated
+}
+
+// LatestVersion returns the app's newest version: the one with the latest
+// parseable date, or the first listed (the AltStore convention is newest
+// first) when no date parses. ok is false if the app has no versions.
+func (a App) LatestVersion() (latest Version, ok bool) {
+	if len(a.Versions) == 0 {
+		return Version{}, false
+	}
+	latest = a.Versions[0]
+	var latestAt time.Time
+	for _, v := range a.Versions {
+		if t, ok := v.ParsedDate(); ok && t.After(latestAt) {
+			latest, latestAt = v, t
+		}
+	}
+	return latest, true
+}
+
+type Version struct {
+	Version              string `json:"version,omitempty"`
+	Date                 string `json:"date,omitempty"`
+	LocalizedDescription string `json:"localizedDescription,omitempty"`
+	DownloadURL          string `json:"downloadURL,omitempty"`
+	Size                 int64  `json:"size,omitempty"`
+	SHA256               string `json:"sha256,omitempty"`
+	MinOSVersion         string `json:"minOSVersion,omitempty"`
+	MaxOSVersion         string `json:"maxOSVersion,omitempty"`
+	Beta                 bool   `json:"beta,omitempty"`
+	// buildVersion intentionally removed
+
+	patreonGated bool    // as on App
+	bareSize     float64 // size as given when it was a plain JSON number, for -assume-size-unit
+}
+
+// ParsedDate returns the version's date as a time. Dates are normalized to
+// RFC3339 during the build, so that is tried first; anything else goes
+// through ParseFlexibleTime. ok is false when the date is empty or doesn't
+// parse.
+func (v Version) ParsedDate() (t time.Time, ok bool) {
+	if t, err := time.Parse(time.RFC3339, v.Date); err == nil {
+		return t, true
+	}
+	t = ParseFlexibleTime(v.Date)
+	return t, !t.IsZero()
+}
+
+type NewsItem struct {
+	Title      string      `json:"title,omitempty"`
+	Identifier string      `json:"identifier,omitempty"`
+	Caption    string      `json:"caption,omitempty"`
+	Date       string      `json:"date,omitempty"`
+	TintColor  string      `json:"tintColor,omitempty"`
+	ImageURL   string      `json:"imageURL,omitempty"`
+	Notify     bool        `json:"notify,omitempty"`
+	URL        string      `json:"url,omitempty"`
+	AppID      interface{} `json:"appID,omitempty"`
+}
+
+// Options selects the optional passes run on top of normalization.
+type Options struct {
+	// input
+	NDJSON              bool   // input holds one source per line, merged in order
+	Format              string // input format: auto, json or plist
+	DetectDupes         bool   // warn about keys repeated within one object
+	DefaultIdentifier   string // identifier for sources without one ("" means HardcodedIdentifier)
+	DefaultSourceURL    string // sourceURL for sources without one ("" means HardcodedSourceURL)
+	Lenient             bool   // strip comments and trailing commas before parsing JSON
+	NoDefaultIdentifier bool   // leave a missing identifier empty
+	NoDefaultSourceURL  bool   // leave a missing sourceURL empty
+	InputEncoding       string // transcode the input from this encoding; "" detects a BOM and assumes UTF-8
+	Strict              bool   // reject JSON input with fields the decoder would ignore
+	ReportNulls         bool   // warn about known fields written as an explicit null
+
+	// app and news passes
+	IncludeCategories      []string          // keep only apps in these categories
+	ExcludeCategories      []string          // then drop apps in these categories
+	IncludeBundleIDs       []string          // keep only apps with these bundle identifiers
+	ExcludeBundleIDs       []string          // then drop apps with these bundle identifiers
+	MaxScreenshots         int               // keep at most this many screenshots per app; 0 keeps all
+	NormalizeVersion       bool              // strip a leading v from version strings
+	SortNews               bool              // order news newest first
+	EmitCount              bool              // add a top-level appCount field
+	RewriteHosts           map[string]string // old host -> new host for download, icon and screenshot URLs
+	AllowDownloadPrefixes  []string          // downloadURL prefixes exempt from the .ipa extension check
+	StampGenerator         bool              // add a trailing generator object with tool name, version and time
+	ToolVersion            string            // build version for the generator stamp ("" means dev)
+	AppTransformers        []AppTransformer  // run on each app after the built-in passes; the CLI sets none
+	StripControl           bool              // remove C0 control characters except \n and \t from every string
+	ASCIIPunct             bool              // map curly quotes, dashes and ellipses in text fields to ASCII
+	RequireFields          []string          // App JSON fields every app must have
+	RequireMode            string            // what to do with apps missing a RequireFields entry: drop or warn ("" means warn)
+	StaleAfter             time.Duration     // warn about apps whose latest version is older than this; 0 disables
+	DropBeta               bool              // remove apps and versions marked beta
+	VersionDescFallback    bool              // fill empty version notes from the app description or subtitle
+	CheckSharedScreenshots bool              // warn when a screenshot URL appears in more than one app
+	ClientOrder            bool              // order apps as the client shows them: featured, then by category and name
+	EncodeURLs             bool              // percent-encode spaces and unsafe characters in every http(s) URL
+	CanonicalPermissions   bool              // rewrite appPermissions as {entitlements: [...], privacy: {...}}
+	DropPatreonGated       bool              // remove apps and versions whose patreon object restricts them to patrons
+	LimitApps              int               // keep only the first this many apps after filtering and sorting; 0 keeps all
+	DropNoDownload         bool              // remove versions without a downloadURL instead of only warning
+	PruneEmptyApps         bool              // remove apps left without versions
+	PreserveOrder          bool              // reject options that reorder apps, versions, news or featured apps
+	AssumeSizeUnit         string            // unit of plain-number sizes: bytes, kb or mb ("" means bytes, unchecked)
+	TitleCaseDevelopers    bool              // title-case all-caps or all-lowercase developer names
+	ResolveNewsAppIDs      bool              // map news appIDs given as an app index or name to the bundleIdentifier
+	PruneNewsOlderThan     time.Duration     // drop news dated longer ago than this; 0 keeps all
+	MinMinOS               string            // drop versions whose minOSVersion is below this; "" disables
+	MaxMinOS               string            // drop versions whose minOSVersion is above this; "" disables
+	DedupFeatured          bool              // drop repeated featuredApps entries, keeping the first
+
+	// output encoding
+	ASCII          bool // escape non-ASCII characters in the output as \uXXXX
+	SortKeys       bool // sort keys of every object that isn't one of our structs
+	ExplicitNotify bool // write "notify": false on news items instead of omitting it
+
+	// network
+	CheckURLs              bool          // probe every DownloadURL and IconURL and warn on failures
+	ResolveDownloads       bool          // rewrite DownloadURLs to their final redirect target
+	VerifyIPA              bool          // download IPAs and compare their Info.plist to the source
+	MaxIPASize             int64         // largest IPA, in bytes, -verify-ipa will download
+	Concurrency            int           // max in-flight network requests
+	HTTPTimeout            time.Duration // connect/response-header timeout for every request
+	UserAgent              string        // User-Agent sent with every request
+	Retries                int           // extra attempts on timeouts, reset connections and 429/5xx
+	CacheDir               string        // keep network results in this directory across runs ("" disables)
+	RefreshCache           bool          // ignore cached results, but save fresh ones
+	MinIconSize            int           // fetch each app icon and warn if either side is below this many pixels; 0 disables
+	InlineIcons            bool          // replace icon URLs with base64 data: URLs
+	MaxInlineIconSize      int64         // largest icon, in bytes, -inline-icons will embed
+	DedupScreenshotContent bool          // download screenshots and point byte-identical ones at the first URL
+	ResolveGitHubReleases  bool          // rewrite GitHub release API downloadURLs to the release's IPA asset
+	GitHubToken            string        // bearer token for GitHub API requests, for higher rate limits
+}
+
+// OptionError reports an invalid Options value or combination, as opposed to
+// a problem with the input. The CLI treats it as a usage error.
+type OptionError struct {
+	Err error
+}
+
+func (e *OptionError) Error() string { return e.Err.Error() }
+
+func (e *OptionError) Unwrap() error { return e.Err }
+
+// Validate checks opts without looking at any input. BuildSources calls it
+// first; callers that read their input from the network or disk can call it
+// earlier to fail fast. Every error it returns is an *OptionError.
+func (opts Options) Validate() error {
+	if opts.PreserveOrder {
+		if sorts := reorderingOptions(opts); len(sorts) > 0 {
+			return &OptionError{fmt.Errorf("-preserve-order can't be combined with %s", strings.Join(sorts, ", "))}
+		}
+	}
+	if err := checkRequireFields(opts.RequireFields, opts.RequireMode); err != nil {
+		return &OptionError{err}
+	}
+	if _, err := parseOSBound("min-minos", opts.MinMinOS); err != nil {
+		return &OptionError{err}
+	}
+	if _, err := parseOSBound("max-minos", opts.MaxMinOS); err != nil {
+		return &OptionError{err}
+	}
+	if opts.AssumeSizeUnit != "" {
+		if _, err := sizeUnitMultiplier(opts.AssumeSizeUnit); err != nil {
+			return &OptionError{err}
+		}
+	}
+	if opts.InputEncoding != "" {
+		if _, err := lookupInputEncoding(opts.InputEncoding); err != nil {
+			return &OptionError{err}
+		}
+	}
+	return nil
+}
+
+// Report collects the warnings raised while processing a source, plus
+// counters for what normalization changed.
+type Report struct {
+	Warnings []string
+
+	Apps, Versions, News int
+	DatesReformatted     int   // dates rewritten to UTC RFC3339
+	DatesUnparsed        int   // dates kept verbatim because no layout matched
+	ScreenshotObjects    int   // screenshot objects flattened to their URL
+	DroppedFields        int   // buildVersion/marketplaceID/patreon values removed
+	TotalSize            int64 // sum of every version's size, in bytes
+	DuplicateDownloads   int   // downloadURLs shared by more than one version of an app
+	StaleApps            int   // apps whose latest version is older than StaleAfter
+	IncompleteApps       int   // apps missing a -require field, dropped or warned about
+	NoScreenshotApps     int   // apps without any screenshot
+	Cancelled            int   // network operations cut short by an interrupt
+}
+
+// Warnf records a warning.
+func (r *Report) Warnf(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// count sets the app, version, news and size totals from the final output.
+func (r *Report) count(out Root) {
+	r.Apps, r.Versions, r.News = len(out.Apps), 0, len(out.News)
+	r.TotalSize = 0
+	for _, app := range out.Apps {
+		r.Versions += len(app.Versions)
+		for _, v := range app.Versions {
+			r.TotalSize += v.Size
+		}
+	}
+}
+
+// cancelled counts err as a cancelled operation, rather than a warning, when
+// it stems from the run being interrupted.
+func (r *Report) cancelled(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		r.Cancelled++
+		return true
+	}
+	return false
+}
+
+// ProcessSource normalizes a raw source document and returns the indented
+// output JSON. The result depends only on the input bytes and opts: dates
+// without a zone are read as UTC, map keys are emitted sorted and nothing
+// time-of-run is embedded unless StampGenerator is set, so running it twice
+// on the same input is byte-identical. Cancelling ctx aborts any network passes.
+func ProcessSource(ctx context.Context, b []byte, opts Options) ([]byte, *Report, error) {
+	out, rep, err := Build(ctx, b, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	outBytes, err := Encode(out, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return outBytes, rep, nil
+}
+
+// Build decodes b and runs the optional passes selected in opts. If ctx
+// is cancelled it returns ctx.Err() along with the partial report.
+func Build(ctx context.Context, b []byte, opts Options) (Root, *Report, error) {
+	return BuildSources(ctx, [][]byte{b}, opts)
+}
+
+// BuildSources is Build for several input documents. Each is decoded on its
+// own, with every input option applied, and they are merged in order as
+// NDJSON lines are, later sources winning; the passes then run once on the
+// result. A decode error names the failing source by its 1-based position.
+func BuildSources(ctx context.Context, srcs [][]byte, opts Options) (Root, *Report, error) {
+	if err := opts.Validate(); err != nil {
+		return Root{}, nil, err
+	}
+	rep := &Report{}
+	var out Root
+	for i, b := range srcs {
+		src, err := decodeRaw(b, opts, rep)
+		if err != nil {
+			if len(srcs) > 1 {
+				err = fmt.Errorf("source %d: %w", i+1, err)
+			}
+			return Root{}, nil, err
+		}
+		if i == 0 {
+			out = src
+		} else {
+			mergeRoots(&out, src)
+		}
+	}
+	// defaults go on after any merging so a later source's real identifier
+	// isn't shadowed by an earlier one's default
+	applyDefaults(&out, opts)
+	if opts.AssumeSizeUnit != "" {
+		if err := assumeSizeUnit(out.Apps, opts.AssumeSizeUnit, rep); err != nil {
+			return Root{}, nil, err
+		}
+	}
+
+	if opts.ResolveNewsAppIDs {
+		// before the filters, while numeric appIDs still index the input's apps
+		n := resolveNewsAppIDs(&out, rep)
+		logv.Infof("resolve-news-appids: %d appIDs rewritten", n)
+	}
+	had := bundleIDSet(out.Apps)
+	if len(opts.IncludeCategories) > 0 || len(opts.ExcludeCategories) > 0 {
+		before := len(out.Apps)
+		out.Apps = filterCategories(out.Apps, opts.IncludeCategories, opts.ExcludeCategories)
+		logv.Infof("category filter: %d of %d apps filtered out", before-len(out.Apps), before)
+	}
+	if len(opts.IncludeBundleIDs) > 0 || len(opts.ExcludeBundleIDs) > 0 {
+		before := len(out.Apps)
+		out.Apps = filterBundleIDs(out.Apps, opts.IncludeBundleIDs, opts.ExcludeBundleIDs)
+		logv.Infof("bundle id filter: %d of %d apps filtered out", before-len(out.Apps), before)
+	}
+	if opts.DropBeta {
+		before := len(out.Apps)
+		n := dropBeta(&out)
+		logv.Infof("drop-beta: %d beta apps and %d beta versions dropped", before-len(out.Apps), n)
+	}
+	if opts.DropPatreonGated {
+		apps, versions := dropPatreonGated(&out)
+		logv.Infof("drop-patreon-gated: %d apps and %d versions dropped", apps, versions)
+	}
+	if opts.MinMinOS != "" || opts.MaxMinOS != "" {
+		min, err := parseOSBound("min-minos", opts.MinMinOS)
+		if err != nil {
+			return Root{}, nil, err
+		}
+		max, err := parseOSBound("max-minos", opts.MaxMinOS)
+		if err != nil {
+			return Root{}, nil, err
+		}
+		dropped, unparsed := filterMinOS(out.Apps, min, max)
+		logv.Infof("minos filter: %d versions dropped, %d with unparseable minOSVersion kept", dropped, unparsed)
+	}
+	if n := checkMissingDownloads(out.Apps, opts.DropNoDownload, rep); n > 0 {
+		logv.Infof("drop-no-download: %d versions dropped", n)
+	}
+	if opts.PruneEmptyApps {
+		// after every pass that drops versions
+		n := pruneEmptyApps(&out)
+		logv.Infof("prune-empty-apps: %d apps without versions pruned", n)
+	}
+	if len(opts.RequireFields) > 0 {
+		var err error
+		if out.Apps, err = requireFields(out.Apps, opts.RequireFields, defaultIfEmpty(opts.RequireMode, "warn"), rep); err != nil {
+			return Root{}, nil, err
+		}
+		logv.Infof("require: %d apps missing required fields", rep.IncompleteApps)
+	}
+	pruneFeatured(&out, had)
+	if opts.DedupFeatured {
+		var n int
+		out.FeaturedApps, n = dedupFeatured(out.FeaturedApps)
+		logv.Infof("dedup-featured: %d repeated featured apps dropped", n)
+	}
+	if opts.NormalizeVersion {
+		normalizeVersionStrings(out.Apps)
+	}
+	limitScreenshots(out.Apps, opts.MaxScreenshots)
+	if len(opts.RewriteHosts) > 0 {
+		// before the network passes so they probe the new hosts
+		n := rewriteHosts(&out, opts.RewriteHosts)
+		logv.Infof("rewrite-host: %d URLs rewritten", n)
+	}
+	if opts.EncodeURLs {
+		n := encodeURLs(&out)
+		logv.Infof("encode-urls: %d URLs re-encoded", n)
+	}
+	if opts.VersionDescFallback {
+		n := fillVersionDescriptions(out.Apps)
+		logv.Infof("version-desc-fallback: %d versions given the app description", n)
+	}
+	if opts.StripControl {
+		n := mapStrings(&out, stripControl)
+		logv.Infof("strip-control: %d strings cleaned", n)
+	}
+	if opts.ASCIIPunct {
+		n := mapTextFields(&out, asciiPunct.Replace)
+		logv.Infof("ascii-punct: %d strings rewritten", n)
+	}
+	if opts.TitleCaseDevelopers {
+		n := 0
+		for i := range out.Apps {
+			if t := titleCaseName(out.Apps[i].DeveloperName); t != out.Apps[i].DeveloperName {
+				logv.Debugf("app %q: developerName %q -> %q", out.Apps[i].Name, out.Apps[i].DeveloperName, t)
+				out.Apps[i].DeveloperName = t
+				n++
+			}
+		}
+		logv.Infof("title-case-developers: %d developer names rewritten", n)
+	}
+	if opts.CanonicalPermissions {
+		n := canonicalizePermissions(out.Apps, rep)
+		logv.Infof("canonical-permissions: %d apps rewritten", n)
+	}
+	// embedder hooks see fully normalized apps; the checks below see
+	// whatever they return
+	transformApps(out.Apps, opts.AppTransformers)
+	out.News = dedupNews(out.News, rep)
+	if opts.PruneNewsOlderThan > 0 {
+		var n int
+		out.News, n = pruneOldNews(out.News, opts.PruneNewsOlderThan, time.Now(), rep)
+		logv.Infof("prune-news-older-than: %d news items pruned", n)
+	}
+	if opts.SortNews {
+		sortNews(out.News)
+	}
+	if opts.ClientOrder {
+		sortClientOrder(&out)
+	}
+	if opts.LimitApps > 0 && len(out.Apps) > opts.LimitApps {
+		// after every filter and sort, so it keeps the first N as published
+		logv.Infof("limit-apps: %d of %d apps dropped", len(out.Apps)-opts.LimitApps, len(out.Apps))
+		out.Apps = out.Apps[:opts.LimitApps]
+		pruneFeatured(&out, had)
+	}
+	checkNewsAppIDs(out, rep)
+	checkOSVersions(out.Apps, rep)
+	checkVersionOrder(out.Apps, rep)
+	allow := opts.AllowDownloadPrefixes
+	if opts.ResolveGitHubReleases {
+		// those are rewritten to the asset URL later
+		allow = append(allow[:len(allow):len(allow)], githubAPIPrefix)
+	}
+	checkDownloadExtensions(out.Apps, allow, rep)
+	checkDuplicateDownloads(out.Apps, rep)
+	checkScreenshots(out.Apps, rep)
+	if opts.CheckSharedScreenshots {
+		checkSharedScreenshots(out.Apps, rep)
+	}
+	if opts.StaleAfter > 0 {
+		checkStale(out.Apps, opts.StaleAfter, time.Now(), rep)
+	}
+	if opts.EmitCount {
+		// after every filter, so it matches the apps actually published
+		out.AppCount = len(out.Apps)
+	}
+	if opts.StampGenerator {
+		out.Generator = &Generator{Name: ToolName, Version: defaultIfEmpty(opts.ToolVersion, "dev"), Generated: time.Now().UTC().Format(time.RFC3339)}
+	}
+
+	client := newHTTPClient(opts)
+	cache, err := openMetaCache(opts.CacheDir, opts.RefreshCache)
+	if err != nil {
+		// a corrupt cache only costs refetching
+		rep.Warnf("cache: %v; starting empty", err)
+		cache, _ = openMetaCache(opts.CacheDir, true)
+	}
+	if opts.ResolveGitHubReleases {
+		// first, so the other passes see the asset URLs
+		resolveGitHubReleases(ctx, client, &out, opts, rep)
+	}
+	if opts.ResolveDownloads {
+		resolveDownloadURLs(ctx, client, &out, opts, rep)
+	}
+	if opts.CheckURLs {
+		checkDownloadURLs(ctx, client, out, opts, rep)
+		checkIconURLs(ctx, client, out, opts, rep)
+	}
+	if opts.DedupScreenshotContent {
+		dedupScreenshotContent(ctx, client, &out, opts, rep)
+	}
+	if opts.MinIconSize > 0 {
+		checkIconSizes(ctx, client, out, opts.MinIconSize, opts, rep)
+	}
+	if opts.InlineIcons {
+		// after the icon checks, which need the real URLs
+		inlineIcons(ctx, client, &out, opts.MaxInlineIconSize, opts, rep)
+	}
+	if opts.VerifyIPA {
+		verifyIPAs(ctx, client, cache, out, opts, rep)
+	}
+	if err := cache.save(); err != nil {
+		rep.Warnf("cache: %v", err)
+	}
+	if err := ctx.Err(); err != nil {
+		rep.count(out)
+		return out, rep, err
+	}
+
+	rep.count(out)
+	return out, rep, nil
+}
+
+// reorderingOptions names the options set in opts that change the order of
+// apps, versions, news or featured apps. Everything else keeps input order:
+// normalization rewrites values in place and filters only remove entries.
+func reorderingOptions(opts Options) []string {
+	var names []string
+	if opts.SortNews {
+		names = append(names, "-sort-news")
+	}
+	if opts.ClientOrder {
+		names = append(names, "-client-order")
+	}
+	return names
+}
+
+// decodeRaw runs the input stage for one document: transcoding, format
+// detection, -lenient, -detect-dupes, the decoder for its format and -strict.
+func decodeRaw(b []byte, opts Options, rep *Report) (Root, error) {
+	b, err := decodeInput(b, opts.InputEncoding)
+	if err != nil {
+		return Root{}, err
+	}
+	isPlist := opts.Format == "plist" || (opts.Format == "auto" || opts.Format == "") && looksLikePlist(b)
+	if opts.Lenient && !isPlist {
+		b = stripLenient(b)
+	}
+	if opts.DetectDupes {
+		dupes, err := findDuplicateKeys(b)
+		for _, d := range dupes {
+			rep.Warnf("%s", d)
+		}
+		if err != nil {
+			// the real parse below reports syntax errors properly
+			logv.Debugf("detect-dupes: stopped early: %v", err)
+		}
+	}
+	var out Root
+	switch {
+	case opts.NDJSON:
+		out, err = decodeNDJSON(b, rep)
+	case isPlist:
+		out, err = decodePlist(b, rep)
+	case opts.Format == "json" || opts.Format == "auto" || opts.Format == "":
+		out, err = decodeSource(b, rep)
+		err = locateJSONError(b, err)
+	default:
+		err = fmt.Errorf("unknown -format %q", opts.Format)
+	}
+	if err != nil {
+		return Root{}, err
+	}
+	if opts.Strict && !isPlist {
+		if err := checkStrict(b, opts.NDJSON); err != nil {
+			return Root{}, err
+		}
+	}
+	if opts.ReportNulls && !isPlist {
+		n := reportNulls(b, opts.NDJSON, rep)
+		logv.Infof("report-nulls: %d explicit null field(s)", n)
+	}
+	return out, nil
+}
+
+// Decode reads b as a plain JSON source and fills in the default identifier
+// and sourceURL, without running any passes. -diff uses it to normalize the
+// existing file; syntax errors carry a line and column.
+func Decode(b []byte, opts Options) (Root, error) {
+	out, err := decodeSource(b, &Report{})
+	if err != nil {
+		return Root{}, locateJSONError(b, err)
+	}
+	applyDefaults(&out, opts)
+	return out, nil
+}
+
+// Encode marshals out with indentation. encoding/json writes map keys
+// in sorted order, which keeps appPermissions and appID output stable.
+func Encode(out Root, opts Options) ([]byte, error) {
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if opts.ExplicitNotify {
+		if b, err = explicitNotify(b); err != nil {
+			return nil, err
+		}
+	}
+	if opts.SortKeys {
+		if b, err = sortJSONKeys(b); err != nil {
+			return nil, err
+		}
+	}
+	if opts.ASCII {
+		b = escapeNonASCII(b)
+	}
+	return b, nil
+}
+
+// decodeSource parses the raw input and builds the normalized Root, counting
+// what it changed in rep.
+func decodeSource(b []byte, rep *Report) (Root, error) {
+	// quick UTF-8 sanity: if file bytes not valid UTF-8 we still attempt to recover
+	if !utf8.Valid(b) {
+		// convert to string and re-decode runes, which replaces invalid sequences with RuneError
+		b = []byte(replaceInvalidUTF8(string(b)))
+	}
+
+	// some aggregators ship an array of sources instead of a single object
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '[' {
+		return decodeSourceArray(b, rep)
+	}
+
+	var raw map[string]interface{}
+	if err := unmarshalNumbers(b, &raw); err != nil {
+		return Root{}, err
+	}
+	return rootFromMap(raw, rep), nil
+}
+
+// decodeSourceArray decodes a top-level array of source objects and merges
+// them in order.
+func decodeSourceArray(b []byte, rep *Report) (Root, error) {
+	var elems []interface{}
+	if err := unmarshalNumbers(b, &elems); err != nil {
+		return Root{}, err
+	}
+	return rootFromArray(elems, rep)
+}
+
+// unmarshalNumbers is json.Unmarshal with UseNumber, so numbers decode as
+// json.Number and integers beyond float64's 2^53 precision (byte counts of
+// multi-gigabyte IPAs) survive exactly.
+func unmarshalNumbers(b []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		// let Unmarshal describe the trailing data as a *json.SyntaxError
+		if err := json.Unmarshal(b, new(interface{})); err != nil {
+			return err
+		}
+		return fmt.Errorf("invalid character after top-level value at offset %d", dec.InputOffset())
+	}
+	return nil
+}
+
+// rootFromArray merges an array of decoded source objects in order.
+func rootFromArray(elems []interface{}, rep *Report) (Root, error) {
+	var out Root
+	for i, e := range elems {
+		m, ok := e.(map[string]interface{})
+		if !ok {
+			return Root{}, fmt.Errorf("top-level array element %d is %s, expected a source object", i, jsonKind(e))
+		}
+		mergeRoots(&out, rootFromMap(m, rep))
+	}
+	return out, nil
+}
+
+// jsonKind names the JSON type of a decoded value for error messages.
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case json.Number, float64:
+		return "a number"
+	case string:
+		return "a string"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// rootFromMap builds the normalized Root from one decoded source object.
+func rootFromMap(raw map[string]interface{}, rep *Report) Root {
+	out := Root{}
+
+	out.Name = getStr(raw, "name")
+	out.Subtitle = getStr(raw, "subtitle")
+	out.Identifier = getStr(raw, "identifier")
+	out.SourceURL = getStr(raw, "sourceURL")
+	out.Description = getStr(raw, "description")
+	out.IconURL = getStr(raw, "iconURL")
+	out.Website = getStr(raw, "website")
+	out.PatreonURL = getStr(raw, "patreonURL")
+	out.HeaderURL = getStr(raw, "headerURL")
+	out.TintColor = getStr(raw, "tintColor")
+
+	// featuredApps
+	if fa, ok := raw["featuredApps"].([]interface{}); ok {
+		for _, v := range fa {
+			if s, ok := v.(string); ok {
+				out.FeaturedApps = append(out.FeaturedApps, sanitizeString(s))
+			}
+		}
+	}
+
+	// apps
+	if appsRaw, ok := raw["apps"].([]interface{}); ok {
+		for _, a := range appsRaw {
+			if am, ok := a.(map[string]interface{}); ok {
+				app := App{}
+				app.Name = getStr(am, "name")
+				app.BundleIdentifier = getStr(am, "bundleIdentifier")
+				app.DeveloperName = getStr(am, "developerName")
+				app.Subtitle = getStr(am, "subtitle")
+				app.LocalizedDescription = getStr(am, "localizedDescription")
+				app.LocalizedNames = localizedStrings(am, "localizedNames", app.Name, rep)
+				app.LocalizedSubtitles = localizedStrings(am, "localizedSubtitles", app.Name, rep)
+				app.LocalizedDescriptions = localizedStrings(am, "localizedDescriptions", app.Name, rep)
+				// the plain fields are the en fallback, so fill them from en
+				// when only the localized object was given
+				app.Name = defaultIfEmpty(app.Name, app.LocalizedNames["en"])
+				app.Subtitle = defaultIfEmpty(app.Subtitle, app.LocalizedSubtitles["en"])
+				app.LocalizedDescription = defaultIfEmpty(app.LocalizedDescription, app.LocalizedDescriptions["en"])
+				app.IconURL = getStr(am, "iconURL")
+				app.TintColor = getStr(am, "tintColor")
+				app.Category = getStr(am, "category")
+
+				// screenshot handling:
+				// prefer explicit screenshotURLs, but if absent, convert screenshots -> screenshotURLs
+				if sUrls, ok := am["screenshotURLs"]; ok {
+					if arr, ok := sUrls.([]interface{}); ok {
+						app.ScreenshotURLs = screenshotURLsFrom(arr, app.Name, rep)
+					}
+				} else if shots, ok := am["screenshots"]; ok {
+					switch sh := shots.(type) {
+					case []interface{}:
+						app.ScreenshotURLs = screenshotURLsFrom(sh, app.Name, rep)
+					case map[string]interface{}:
+						// older AltStore sources key screenshots by device
+						for _, device := range screenshotDevices(sh) {
+							if arr, ok := sh[device].([]interface{}); ok {
+								app.ScreenshotURLs = append(app.ScreenshotURLs, screenshotURLsFrom(arr, app.Name, rep)...)
+							}
+						}
+					}
+				}
+
+				// preview video: videoURL, or the first entry of previewVideos
+				app.VideoURL = getStr(am, "videoURL")
+				if app.VideoURL == "" {
+					switch pv := am["previewVideos"].(type) {
+					case string:
+						app.VideoURL = sanitizeString(pv)
+					case []interface{}:
+						for _, item := range pv {
+							switch it := item.(type) {
+							case string:
+								app.VideoURL = sanitizeString(it)
+							case map[string]interface{}:
+								app.VideoURL = getStr(it, "url")
+							}
+							if app.VideoURL != "" {
+								break
+							}
+						}
+					}
+				}
+				if app.VideoURL != "" && !strings.HasPrefix(strings.ToLower(app.VideoURL), "https://") {
+					rep.Warnf("app %q: videoURL %q is not an https URL", app.Name, app.VideoURL)
+				}
+				if b, ok := am["beta"].(bool); ok {
+					app.Beta = b
+				}
+				app.patreonGated = isPatreonGated(am["patreon"])
+
+				// versions: convert date → UTC RFC3339, ignore buildVersion
+				if versionsRaw, ok := am["versions"].([]interface{}); ok {
+					for _, vr := range versionsRaw {
+						if vm, ok := vr.(map[string]interface{}); ok {
+							v := versionFromMap(vm, app.Name, rep)
+							if _, ok := vm["buildVersion"]; ok {
+								rep.DroppedFields++
+								logv.Debugf("app %q version %q: dropped buildVersion", app.Name, v.Version)
+							}
+							app.Versions = append(app.Versions, v)
+						}
+					}
+				}
+
+				// very old sources put a single version's fields directly on the
+				// app; synthesize a one-element versions list from them
+				if len(app.Versions) == 0 && getStr(am, "version") != "" && getStr(am, "downloadURL") != "" {
+					v := versionFromMap(am, app.Name, rep)
+					// on the app, localizedDescription is the app's own text
+					v.LocalizedDescription = getStr(am, "versionDescription")
+					app.Versions = []Version{v}
+					logv.Debugf("app %q: built versions from legacy top-level version %q", app.Name, v.Version)
+				}
+
+				// preserve appPermissions as raw JSON if present
+				if ap, ok := am["appPermissions"]; ok {
+					if rawBytes, err := json.Marshal(ap); err == nil {
+						app.AppPermissions = json.RawMessage(rawBytes)
+					}
+				}
+
+				// explicitly skip marketplaceID, patreon, buildVersion by not copying them
+				for _, k := range []string{"marketplaceID", "patreon", "buildVersion"} {
+					if _, ok := am[k]; ok {
+						rep.DroppedFields++
+						logv.Debugf("app %q: dropped %s", app.Name, k)
+					}
+				}
+
+				out.Apps = append(out.Apps, app)
+
+			}
+		}
+	}
+
+	// news: copy and normalize dates
+	if newsRaw, ok := raw["news"].([]interface{}); ok {
+		for _, n := range newsRaw {
+			if nm, ok := n.(map[string]interface{}); ok {
+				ni := NewsItem{
+					Title:      getStr(nm, "title"),
+					Identifier: getStr(nm, "identifier"),
+					Caption:    getStr(nm, "caption"),
+					TintColor:  getStr(nm, "tintColor"),
+					ImageURL:   getStr(nm, "imageURL"),
+					URL:        getStr(nm, "url"),
+				}
+				// notify may be bool
+				if nb, ok := nm["notify"].(bool); ok {
+					ni.Notify = nb
+				}
+				// appID could be null or a string - preserve whatever value was
+				if v, ok := nm["appID"]; ok {
+					ni.AppID = v
+				}
+				if dateRaw, ok := nm["date"].(string); ok && dateRaw != "" {
+					ni.Date = normalizeDate(dateRaw, fmt.Sprintf("news %q", ni.Identifier), rep)
+				}
+				out.News = append(out.News, ni)
+
+			}
+		}
+	}
+
+	return out
+}
+
+// screenshotURLsFrom flattens a screenshot array whose items are URL strings
+// or objects carrying an imageURL (or url).
+func screenshotURLsFrom(arr []interface{}, appName string, rep *Report) []string {
+	var urls []string
+	for _, item := range arr {
+		switch it := item.(type) {
+		case string:
+			urls = append(urls, sanitizeString(it))
+		case map[string]interface{}:
+			// try imageURL or url
+			s := getStr(it, "imageURL")
+			if s == "" {
+				s = getStr(it, "url")
+			}
+			if s != "" {
+				urls = append(urls, s)
+				rep.ScreenshotObjects++
+				logv.Debugf("app %q: screenshot object converted to %s", appName, s)
+			}
+		}
+	}
+	return urls
+}
+
+// screenshotDevices orders the keys of a device-keyed screenshots object:
+// iphone, then ipad, then any other devices alphabetically.
+func screenshotDevices(m map[string]interface{}) []string {
+	rank := func(k string) int {
+		switch strings.ToLower(k) {
+		case "iphone":
+			return 0
+		case "ipad":
+			return 1
+		}
+		return 2
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ri, rj := rank(keys[i]), rank(keys[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// versionFromMap builds a Version from a version object, normalizing its
+// date and size. appName only labels -verbose logs.
+func versionFromMap(vm map[string]interface{}, appName string, rep *Report) Version {
+	v := Version{
+		Version:              getStr(vm, "version"),
+		LocalizedDescription: getStr(vm, "localizedDescription"),
+		DownloadURL:          getStr(vm, "downloadURL"),
+		MinOSVersion:         getStr(vm, "minOSVersion"),
+		MaxOSVersion:         getStr(vm, "maxOSVersion"),
+	}
+	if b, ok := vm["beta"].(bool); ok {
+		v.Beta = b
+	}
+	v.patreonGated = isPatreonGated(vm["patreon"])
+	// checksums are compared as lowercase hex; anything else is kept but flagged
+	if sum := strings.ToLower(strings.TrimSpace(getStr(vm, "sha256"))); sum != "" {
+		if !isSHA256Hex(sum) {
+			rep.Warnf("app %q version %q: sha256 %q is not 64 hex digits", appName, v.Version, sum)
+		}
+		v.SHA256 = sum
+	}
+	// date normalization; some tools (and legacy top-level versions) write
+	// versionDate instead, which is only used when date is absent or empty
+	dateStr := getStr(vm, "date")
+	if dateStr == "" {
+		dateStr = getStr(vm, "versionDate")
+	}
+	if dateStr != "" {
+		v.Date = normalizeDate(dateStr, fmt.Sprintf("app %q version %q", appName, v.Version), rep)
+	}
+	// size normalization
+	if sizeV, ok := vm["size"]; ok {
+		switch n := sizeV.(type) {
+		case json.Number:
+			if size, err := n.Int64(); err == nil {
+				v.Size = size
+			} else if f, err := n.Float64(); err == nil {
+				// fractional or exponent form, e.g. 1.2e9
+				v.Size = int64(f)
+			}
+			v.bareSize, _ = n.Float64()
+		case float64:
+			v.Size, v.bareSize = int64(n), n
+		case int:
+			v.Size, v.bareSize = int64(n), float64(n)
+		case int64:
+			v.Size, v.bareSize = n, float64(n)
+		case string:
+			if size, ok := parseSize(n); ok {
+				v.Size = size
+			} else if strings.TrimSpace(n) != "" {
+				rep.Warnf("app %q version %q: unrecognized size %q", appName, v.Version, n)
+			}
+		}
+	}
+	return v
+}
+
+// isSHA256Hex reports whether s is a lowercase hex SHA-256 digest.
+func isSHA256Hex(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// sizeUnits maps size suffixes to byte multipliers. Sources that write
+// "100 MB" mean 100 MiB, so KB/MB/GB are binary like their KiB/MiB/GiB forms.
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1 << 30,
+	"gib": 1 << 30,
+	"t":   1 << 40,
+	"tb":  1 << 40,
+	"tib": 1 << 40,
+}
+
+// parseSize reads a size given as a string: a plain byte count such as
+// "104857600" or a number with a unit such as "100 MB" or "1.5GB".
+func parseSize(s string) (int64, bool) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, n >= 0
+	}
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i <= 0 {
+		return 0, false
+	}
+	num, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, false
+	}
+	mult, ok := sizeUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, false
+	}
+	return int64(num * float64(mult)), true
+}
+
+// HumanizeSize formats a byte count with one decimal place, e.g. "1.5 MB".
+// Units are binary (1 KB = 1024 bytes, as parseSize reads them) unless
+// decimal is set, in which case 1 KB = 1000 bytes. Counts below 1 KB are
+// plain bytes.
+func HumanizeSize(n int64, decimal bool) string {
+	base := 1024.0
+	if decimal {
+		base = 1000
+	}
+	v := float64(n)
+	if v < base && v > -base {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+	units := []string{"KB", "MB", "GB", "TB"}
+	for i, unit := range units {
+		v /= base
+		// don't print 1024.0 KB for 1048575 bytes
+		if math.Round(v*10)/10 < base || i == len(units)-1 {
+			return strconv.FormatFloat(v, 'f', 1, 64) + " " + unit
+		}
+	}
+	panic("unreachable")
+}
+
+// RelativeDate describes t relative to now in whole calendar days (UTC), e.g.
+// "today", "yesterday", "3 days ago", "2 months ago" or, for dates after now,
+// "tomorrow" and "in 5 days". Weeks, months and years are rounded down.
+func RelativeDate(t, now time.Time) string {
+	day := func(t time.Time) time.Time {
+		y, m, d := t.UTC().Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	days := int(day(now).Sub(day(t)).Hours() / 24)
+	switch days {
+	case 0:
+		return "today"
+	case 1:
+		return "yesterday"
+	case -1:
+		return "tomorrow"
+	}
+	n := days
+	if n < 0 {
+		n = -n
+	}
+	unit := "day"
+	switch {
+	case n >= 365:
+		n, unit = n/365, "year"
+	case n >= 30:
+		n, unit = n/30, "month"
+	case n >= 7:
+		n, unit = n/7, "week"
+	}
+	span := strconv.Itoa(n) + " " + unit
+	if n != 1 {
+		span += "s"
+	}
+	if days < 0 {
+		return "in " + span
+	}
+	return span + " ago"
+}
+
+// getStr reads m[k] as a string, coercing numbers, bools and objects.
+func getStr(m map[string]interface{}, k string) string {
+	if v, ok := m[k]; ok {
+		// treat explicit null as empty string
+		if v == nil {
+			return ""
+		}
+		switch vv := v.(type) {
+		case string:
+			return sanitizeString(vv)
+		case json.Number:
+			// number -> string, exactly as written
+			return vv.String()
+		case float64:
+			// number -> string
+			return fmt.Sprintf("%v", vv)
+		case bool:
+			return fmt.Sprintf("%v", vv)
+		default:
+			// if someone put an object where a string was expected, try to marshal it to string
+			if marsh, err := json.Marshal(vv); err == nil {
+				return sanitizeString(string(marsh))
+			}
+		}
+	}
+	return ""
+}
+
+// applyDefaults fills in a missing identifier and sourceURL, preferring
+// opts.DefaultIdentifier/DefaultSourceURL (from the flags or the
+// RIPEREPO_IDENTIFIER/RIPEREPO_SOURCE_URL environment) over the compiled-in
+// ones. NoDefaultIdentifier/NoDefaultSourceURL leave the field as the input
+// had it, possibly empty.
+func applyDefaults(out *Root, opts Options) {
+	if !opts.NoDefaultIdentifier {
+		out.Identifier = defaultIfEmpty(out.Identifier, defaultIfEmpty(opts.DefaultIdentifier, HardcodedIdentifier))
+	}
+	if !opts.NoDefaultSourceURL {
+		out.SourceURL = defaultIfEmpty(out.SourceURL, defaultIfEmpty(opts.DefaultSourceURL, HardcodedSourceURL))
+	}
+}
+
+// localizedStrings reads a locale -> text object from m[key]. Entries that
+// aren't strings are dropped with a warning; nil means no localizations.
+func localizedStrings(m map[string]interface{}, key, appName string, rep *Report) map[string]string {
+	obj, ok := m[key].(map[string]interface{})
+	if !ok {
+		if _, present := m[key]; present {
+			rep.Warnf("app %q: %s is %s, not an object of locale -> text; dropped", appName, key, jsonKind(m[key]))
+		}
+		return nil
+	}
+	out := make(map[string]string, len(obj))
+	for locale, v := range obj {
+		s, ok := v.(string)
+		locale = strings.TrimSpace(locale)
+		if !ok || locale == "" {
+			rep.Warnf("app %q: %s[%q] is not a localized string; dropped", appName, key, locale)
+			continue
+		}
+		out[locale] = sanitizeString(s)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func defaultIfEmpty(s, def string) string {
+	if strings.TrimSpace(s) == "" {
+		return def
+	}
+	return s
+}
+
+// sanitizeString ensures we return a string with valid UTF-8 (replace bad bytes)
+func sanitizeString(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	return replaceInvalidUTF8(s)
+}
+
+// escapeNonASCII rewrites every non-ASCII rune in marshaled JSON as a \uXXXX
+// escape, using a surrogate pair above U+FFFF. Outside strings JSON is pure
+// ASCII, so this can work on the raw bytes and still yields valid JSON.
+func escapeNonASCII(b []byte) []byte {
+	var buf bytes.Buffer
+	buf.Grow(len(b))
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		switch {
+		case r < utf8.RuneSelf:
+			buf.WriteByte(b[0])
+		case r > 0xFFFF:
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&buf, "\\u%04x\\u%04x", r1, r2)
+		default:
+			fmt.Fprintf(&buf, "\\u%04x", r)
+		}
+		b = b[size:]
+	}
+	return buf.Bytes()
+}
+
+// replaceInvalidUTF8 decodes runes, replacing invalid sequences with RuneError
+func replaceInvalidUTF8(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			// invalid single byte sequence -> append Unicode replacement char
+			b.WriteRune(utf8.RuneError)
+			i++
+		} else {
+			b.WriteRune(r)
+			i += size
+		}
+	}
+	return b.String()
+}
+
+// NormalizeDate rewrites s as UTC RFC3339, or returns it unchanged when no
+// layout matches.
+func NormalizeDate(s string) string {
+	if t := ParseFlexibleTime(s); !t.IsZero() {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return s
+}
+
+// normalizeDate rewrites s as UTC RFC3339, or returns it unchanged when no
+// layout matches. what names the owning item in -verbose logs.
+func normalizeDate(s, what string, rep *Report) string {
+	parsed, layout := parseFlexibleTimeLayout(s)
+	if parsed.IsZero() {
+		rep.DatesUnparsed++
+		logv.Debugf("%s: date %q matched no layout, kept as-is", what, s)
+		return s
+	}
+	d := parsed.UTC().Format(time.RFC3339)
+	logv.Debugf("%s: date %q parsed via layout %q -> %s", what, s, layout, d)
+	if d != s {
+		rep.DatesReformatted++
+	}
+	return d
+}
+
+// flexibleLayouts are the layouts ParseFlexibleTime tries, in order. Layouts
+// without a zone are read as UTC by time.Parse; never fall back to
+// time.Local, or the output would depend on the machine running the tool.
+var flexibleLayouts = []string{
+	time.RFC3339, // 2006-01-02T15:04:05Z07:00
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z", // explicit Z (rare)
+	"2006-01-02T15:04:05",  // no zone
+	"2006-01-02T15:04",     // minutes only
+	"2006-01-02 15:04:05",  // space separator
+	"2006-01-02",           // date only
+}
+
+// ParseFlexibleTime parses a loosely formatted timestamp. The built-in
+// layouts are tried first, then extraLayouts in the order given, and the
+// first match wins, so an extra layout can't change how an input the
+// built-ins already accept is read. Surrounding spaces are ignored. On
+// failure it returns the zero time; check with IsZero.
+func ParseFlexibleTime(s string, extraLayouts ...string) time.Time {
+	t, _ := parseFlexibleTimeLayout(s, extraLayouts...)
+	return t
+}
+
+// parseFlexibleTimeLayout is ParseFlexibleTime that also returns the layout
+// which matched, for -verbose logging.
+func parseFlexibleTimeLayout(s string, extraLayouts ...string) (time.Time, string) {
+	s = strings.TrimSpace(s)
+	for _, l := range flexibleLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t, l
+		}
+	}
+	for _, l := range extraLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t, l
+		}
+	}
+	return time.Time{}, ""
+}
@@ -0,0 +1,297 @@
+package riperepo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProcessSourceDeterministic(t *testing.T) {
+	in := []byte(`{
+  "name": "Repo",
+  "apps": [{
+    "name": "App",
+    "bundleIdentifier": "com.example.app",
+    "appPermissions": {"zeta": "z", "alpha": "a", "mid": {"b": 2, "a": 1}},
+    "versions": [{"version": "1.0", "date": "2024-03-01T10:00:00", "downloadURL": "https://example.com/app.ipa"}]
+  }]
+}`)
+	first, _, err := ProcessSource(context.Background(), in, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _, err := ProcessSource(context.Background(), in, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Fatalf("output differs between runs:\n%s\n---\n%s", first, second)
+	}
+	if !strings.Contains(string(first), `"2024-03-01T10:00:00Z"`) {
+		t.Errorf("zone-less date not read as UTC:\n%s", first)
+	}
+}
+
+// decodeString decodes a source given as a JSON string, failing the test on
+// a parse error.
+func decodeString(t *testing.T, src string) (Root, *Report) {
+	t.Helper()
+	rep := &Report{}
+	out, err := decodeSource([]byte(src), rep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out, rep
+}
+
+func TestLegacyTopLevelVersion(t *testing.T) {
+	out, _ := decodeString(t, `{"apps": [{
+		"name": "Old", "bundleIdentifier": "com.example.old",
+		"localizedDescription": "The app",
+		"version": "2.0", "date": "2023-06-30", "downloadURL": "https://example.com/old.ipa",
+		"size": "5 MB", "versionDescription": "Fixes"
+	}]}`)
+	want := Version{
+		Version:              "2.0",
+		Date:                 "2023-06-30T00:00:00Z",
+		LocalizedDescription: "Fixes",
+		DownloadURL:          "https://example.com/old.ipa",
+		Size:                 5 << 20,
+	}
+	if got := out.Apps[0].Versions; len(got) != 1 || got[0] != want {
+		t.Errorf("versions = %+v, want [%+v]", got, want)
+	}
+	if got := out.Apps[0].LocalizedDescription; got != "The app" {
+		t.Errorf("app description = %q, want the app's own", got)
+	}
+
+	// a versions array wins over the legacy fields
+	out, _ = decodeString(t, `{"apps": [{
+		"name": "Both", "version": "9.9", "downloadURL": "https://example.com/stale.ipa",
+		"versions": [{"version": "3.0", "downloadURL": "https://example.com/new.ipa"}]
+	}]}`)
+	if got := out.Apps[0].Versions; len(got) != 1 || got[0].Version != "3.0" {
+		t.Errorf("versions = %+v, want only 3.0", got)
+	}
+
+	// without a downloadURL nothing is synthesized
+	out, _ = decodeString(t, `{"apps": [{"name": "NoURL", "version": "1.0"}]}`)
+	if got := out.Apps[0].Versions; len(got) != 0 {
+		t.Errorf("versions = %+v, want none", got)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+		ok   bool
+	}{
+		{"104857600", 104857600, true},
+		{"100 MB", 100 << 20, true},
+		{"100MB", 100 << 20, true},
+		{"1.5 gb", 3 << 29, true},
+		{" 512 KiB ", 512 << 10, true},
+		{"0", 0, true},
+		{"-5", 0, false},
+		{"MB", 0, false},
+		{"100 parsecs", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseSize(tt.in)
+		if ok != tt.ok || ok && got != tt.want {
+			t.Errorf("parseSize(%q) = %d, %v; want %d, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	out, _ := decodeString(t, `{"apps": [{"name": "A", "versions": [
+		{"version": "1", "size": "104857600"},
+		{"version": "2", "size": "100 MB"}
+	]}]}`)
+	for _, v := range out.Apps[0].Versions {
+		if v.Size != 104857600 {
+			t.Errorf("version %s: size = %d, want 104857600", v.Version, v.Size)
+		}
+	}
+}
+
+func TestLargeSizeExact(t *testing.T) {
+	// 2^53 + 1 is the first integer a float64 can't hold
+	out, _ := decodeString(t, `{"apps": [{"name": "A", "versions": [{"version": "1", "size": 9007199254740993}]}]}`)
+	if got := out.Apps[0].Versions[0].Size; got != 9007199254740993 {
+		t.Errorf("size = %d, want 9007199254740993", got)
+	}
+}
+
+func TestDeviceKeyedScreenshots(t *testing.T) {
+	// the shape older AltStore sources used, with objects carrying a size
+	out, rep := decodeString(t, `{"apps": [{"name": "Delta", "screenshots": {
+		"ipad": [
+			{"imageURL": "https://example.com/ipad1.png", "width": 2048, "height": 2732}
+		],
+		"appletv": ["https://example.com/tv1.png"],
+		"iphone": [
+			{"imageURL": "https://example.com/iphone1.png", "width": 1170, "height": 2532},
+			{"imageURL": "https://example.com/iphone2.png", "width": 1170, "height": 2532}
+		]
+	}}]}`)
+	want := []string{
+		"https://example.com/iphone1.png",
+		"https://example.com/iphone2.png",
+		"https://example.com/ipad1.png",
+		"https://example.com/tv1.png",
+	}
+	if got := out.Apps[0].ScreenshotURLs; !reflect.DeepEqual(got, want) {
+		t.Errorf("screenshotURLs = %q, want %q", got, want)
+	}
+	if rep.ScreenshotObjects != 3 {
+		t.Errorf("ScreenshotObjects = %d, want 3", rep.ScreenshotObjects)
+	}
+}
+
+func TestLocalizedStrings(t *testing.T) {
+	out, rep := decodeString(t, `{"apps": [{
+		"bundleIdentifier": "com.a",
+		"localizedNames": {"en": "Notes", "de": "Notizen", "ja": "メモ"},
+		"subtitle": "Take notes",
+		"localizedSubtitles": {"de": "Notizen machen", "fr": 42},
+		"localizedDescriptions": "not an object"
+	}]}`)
+	app := out.Apps[0]
+	if want := map[string]string{"en": "Notes", "de": "Notizen", "ja": "メモ"}; !reflect.DeepEqual(app.LocalizedNames, want) {
+		t.Errorf("localizedNames = %v, want %v", app.LocalizedNames, want)
+	}
+	if app.Name != "Notes" {
+		t.Errorf("name = %q, want it filled from the en entry", app.Name)
+	}
+	if want := map[string]string{"de": "Notizen machen"}; !reflect.DeepEqual(app.LocalizedSubtitles, want) {
+		t.Errorf("localizedSubtitles = %v, want %v", app.LocalizedSubtitles, want)
+	}
+	if app.Subtitle != "Take notes" {
+		t.Errorf("subtitle = %q, want the plain field kept", app.Subtitle)
+	}
+	if app.LocalizedDescriptions != nil {
+		t.Errorf("localizedDescriptions = %v, want nil", app.LocalizedDescriptions)
+	}
+	if len(rep.Warnings) != 2 {
+		t.Errorf("warnings = %q, want one for the number and one for the string", rep.Warnings)
+	}
+
+	// without localized objects nothing changes
+	out, rep = decodeString(t, `{"apps": [{"name": "Plain", "subtitle": "Sub"}]}`)
+	if app := out.Apps[0]; app.LocalizedNames != nil || app.LocalizedSubtitles != nil || app.Name != "Plain" || len(rep.Warnings) != 0 {
+		t.Errorf("plain app = %+v, warnings %q", app, rep.Warnings)
+	}
+}
+
+func TestHumanizeSize(t *testing.T) {
+	tests := []struct {
+		n       int64
+		decimal bool
+		want    string
+	}{
+		{0, false, "0 B"},
+		{1023, false, "1023 B"},
+		{1024, false, "1.0 KB"},
+		{1536, false, "1.5 KB"},
+		{1048575, false, "1.0 MB"}, // 1023.999 KB rounds up a unit, not to "1024.0 KB"
+		{1048576, false, "1.0 MB"},
+		{1073741824, false, "1.0 GB"},
+		{1 << 50, false, "1024.0 TB"},
+		{999, true, "999 B"},
+		{1000, true, "1.0 KB"},
+		{1023, true, "1.0 KB"},
+		{999999, true, "1.0 MB"},
+		{1000000, true, "1.0 MB"},
+	}
+	for _, tt := range tests {
+		if got := HumanizeSize(tt.n, tt.decimal); got != tt.want {
+			t.Errorf("HumanizeSize(%d, %v) = %q, want %q", tt.n, tt.decimal, got, tt.want)
+		}
+	}
+}
+
+func TestVersionDateAlias(t *testing.T) {
+	out, _ := decodeString(t, `{"apps": [{"name": "A", "versions": [
+		{"version": "1", "versionDate": "2024-05-06 07:08:09"},
+		{"version": "2", "date": "2024-01-01", "versionDate": "1999-01-01"},
+		{"version": "3", "date": "", "versionDate": "2024-02-02"}
+	]}]}`)
+	want := []string{"2024-05-06T07:08:09Z", "2024-01-01T00:00:00Z", "2024-02-02T00:00:00Z"}
+	for i, v := range out.Apps[0].Versions {
+		if v.Date != want[i] {
+			t.Errorf("version %s: date = %q, want %q", v.Version, v.Date, want[i])
+		}
+	}
+}
+
+func TestVersionParsedDate(t *testing.T) {
+	tests := []struct {
+		date string
+		want string // RFC 3339 in UTC; empty means not ok
+	}{
+		{"2024-03-05T10:20:30Z", "2024-03-05T10:20:30Z"},
+		{"2024-03-05T12:20:30+02:00", "2024-03-05T10:20:30Z"},
+		{"2024-03-05", "2024-03-05T00:00:00Z"},
+		{" 2024-03-05 10:20:30 ", "2024-03-05T10:20:30Z"},
+		{"2024-03-05T10:20", "2024-03-05T10:20:00Z"},
+		{"2024-3-5", ""},
+		{"", ""},
+		{"soon", ""},
+		{"2024-13-45", ""},
+	}
+	for _, tt := range tests {
+		got, ok := Version{Date: tt.date}.ParsedDate()
+		if tt.want == "" {
+			if ok {
+				t.Errorf("ParsedDate(%q) = %v, want failure", tt.date, got)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("ParsedDate(%q) failed, want %s", tt.date, tt.want)
+		} else if s := got.UTC().Format(time.RFC3339); s != tt.want {
+			t.Errorf("ParsedDate(%q) = %s, want %s", tt.date, s, tt.want)
+		}
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{"zero", Options{}, false},
+		{"preserve-order alone", Options{PreserveOrder: true}, false},
+		{"preserve-order with sort-news", Options{PreserveOrder: true, SortNews: true}, true},
+		{"preserve-order with client-order", Options{PreserveOrder: true, ClientOrder: true}, true},
+		{"require-mode drop", Options{RequireFields: []string{"iconURL"}, RequireMode: "drop"}, false},
+		{"require-mode bogus", Options{RequireFields: []string{"iconURL"}, RequireMode: "bogus"}, true},
+		{"require-mode bogus without fields", Options{RequireMode: "bogus"}, true},
+		{"require unknown field", Options{RequireFields: []string{"iconUrl"}}, true},
+		{"minos bounds", Options{MinMinOS: "12", MaxMinOS: "16.4.1"}, false},
+		{"max-minos not dotted", Options{MaxMinOS: "abc"}, true},
+		{"min-minos not dotted", Options{MinMinOS: "14.x"}, true},
+		{"assume-size-unit MB", Options{AssumeSizeUnit: "MB"}, false},
+		{"assume-size-unit gb", Options{AssumeSizeUnit: "gb"}, true},
+		{"input-encoding UTF_16LE", Options{InputEncoding: "UTF_16LE"}, false},
+		{"input-encoding foo", Options{InputEncoding: "foo"}, true},
+	}
+	for _, tt := range tests {
+		err := tt.opts.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		var optErr *OptionError
+		if err != nil && !errors.As(err, &optErr) {
+			t.Errorf("%s: Validate() = %T, want an *OptionError", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package riperepo
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxScreenshotSize bounds each download made by dedupScreenshotContent.
+const maxScreenshotSize = 32 << 20
+
+// dedupScreenshotContent downloads every distinct screenshot URL in out,
+// hashes it, and rewrites URLs whose content matches an earlier URL's to
+// that first-seen URL, warning about each rewrite. A rewritten URL that its
+// app already lists is dropped instead; repeats that were in the input are
+// kept. Screenshots that fail to download are left alone with a warning.
+func dedupScreenshotContent(ctx context.Context, client *http.Client, out *Root, opts Options, rep *Report) {
+	type target struct {
+		url string
+		sum [sha256.Size]byte
+	}
+	var targets []*target
+	seen := map[string]bool{}
+	for _, app := range out.Apps {
+		for _, u := range app.ScreenshotURLs {
+			if !seen[u] {
+				seen[u] = true
+				targets = append(targets, &target{url: u})
+			}
+		}
+	}
+
+	logv.Infof("hashing %d screenshots", len(targets))
+	errs := runConcurrent(targets, opts.Concurrency, func(t *target) (err error) {
+		t.sum, err = fetchSHA256(ctx, client, t.url, maxScreenshotSize)
+		return err
+	})
+
+	canonical := map[[sha256.Size]byte]string{}
+	rewrite := map[string]string{}
+	for i, t := range targets {
+		if errs[i] != nil {
+			if !rep.cancelled(errs[i]) {
+				rep.Warnf("screenshot %s: not compared: %v", t.url, errs[i])
+			}
+			continue
+		}
+		if first, ok := canonical[t.sum]; ok {
+			rewrite[t.url] = first
+			rep.Warnf("screenshot %s has the same content as %s; using the latter", t.url, first)
+			continue
+		}
+		canonical[t.sum] = t.url
+	}
+	if len(rewrite) == 0 {
+		return
+	}
+	for i := range out.Apps {
+		app := &out.Apps[i]
+		// only rewritten entries are dropped; repeats the input already had
+		// are left to the user
+		have := stringSet(app.ScreenshotURLs)
+		kept := app.ScreenshotURLs[:0]
+		for _, u := range app.ScreenshotURLs {
+			if c, ok := rewrite[u]; ok {
+				if have[c] {
+					logv.Debugf("app %q: screenshot %s dropped, the app already has %s", app.Name, u, c)
+					continue
+				}
+				u = c
+				have[c] = true
+			}
+			kept = append(kept, u)
+		}
+		app.ScreenshotURLs = kept
+	}
+}
+
+// fetchSHA256 downloads url, refusing more than maxSize bytes, and returns
+// the SHA-256 of its body.
+func fetchSHA256(ctx context.Context, client *http.Client, url string, maxSize int64) (sum [sha256.Size]byte, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return sum, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return sum, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return sum, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	h := sha256.New()
+	n, err := io.Copy(h, io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return sum, err
+	}
+	if n > maxSize {
+		return sum, fmt.Errorf("over the %d byte limit", maxSize)
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
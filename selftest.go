@@ -0,0 +1,59 @@
+package riperepo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// SelfTest checks that normalization is idempotent: it processes b, then
+// processes that output again, and describes the lines where the two differ.
+// Network passes and the generator stamp are switched off since neither is
+// part of normalization. The second pass always reads plain UTF-8 JSON and
+// doesn't rewrite hosts again.
+func SelfTest(ctx context.Context, b []byte, opts Options) ([]string, error) {
+	opts.CheckURLs, opts.ResolveDownloads, opts.VerifyIPA = false, false, false
+	opts.MinIconSize, opts.InlineIcons, opts.DedupScreenshotContent = 0, false, false
+	opts.StampGenerator, opts.ResolveGitHubReleases = false, false
+	first, _, err := ProcessSource(ctx, b, opts)
+	if err != nil {
+		return nil, fmt.Errorf("first pass: %w", err)
+	}
+	opts.NDJSON, opts.Format, opts.Lenient, opts.InputEncoding = false, "json", false, ""
+	// the first pass wrote every size in bytes
+	opts.AssumeSizeUnit = ""
+	// and moved every host once; a chained map such as a=b,b=c would move
+	// them again
+	opts.RewriteHosts = nil
+	second, _, err := ProcessSource(ctx, first, opts)
+	if err != nil {
+		return nil, fmt.Errorf("second pass: %w", err)
+	}
+	return diffLines(first, second, 20), nil
+}
+
+// diffLines lists up to max lines that differ between a and b, by line
+// number. It is a positional comparison, not a minimal diff, which is enough
+// to point at the first place output drifted.
+func diffLines(a, b []byte, max int) []string {
+	la, lb := bytes.Split(a, []byte("\n")), bytes.Split(b, []byte("\n"))
+	var out []string
+	for i := 0; i < len(la) || i < len(lb); i++ {
+		var x, y []byte
+		if i < len(la) {
+			x = la[i]
+		}
+		if i < len(lb) {
+			y = lb[i]
+		}
+		if bytes.Equal(x, y) {
+			continue
+		}
+		if len(out) == max {
+			out = append(out, "...")
+			break
+		}
+		out = append(out, fmt.Sprintf("line %d:\n- %s\n+ %s", i+1, x, y))
+	}
+	return out
+}
@@ -0,0 +1,33 @@
+package riperepo
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSelfTestAssumeSizeUnit(t *testing.T) {
+	src := []byte(`{"apps": [{"name": "A", "bundleIdentifier": "com.a", "versions": [{"version": "1", "size": 12}]}]}`)
+	for _, unit := range []string{"bytes", "kb", "mb"} {
+		diffs, err := SelfTest(context.Background(), src, Options{AssumeSizeUnit: unit})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diffs) > 0 {
+			t.Errorf("%s: selftest found drift:\n%s", unit, strings.Join(diffs, "\n"))
+		}
+	}
+}
+
+func TestSelfTestChainedRewriteHosts(t *testing.T) {
+	src := []byte(`{"apps": [{"name": "A", "bundleIdentifier": "com.a", "iconURL": "https://a.example/icon.png",
+		"versions": [{"version": "1", "downloadURL": "https://a.example/a.ipa", "size": 1048576}]}]}`)
+	opts := Options{RewriteHosts: map[string]string{"a.example": "b.example", "b.example": "c.example"}}
+	diffs, err := SelfTest(context.Background(), src, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) > 0 {
+		t.Errorf("selftest found drift:\n%s", strings.Join(diffs, "\n"))
+	}
+}
@@ -0,0 +1,202 @@
+package riperepo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// jsonObject is a decoded JSON object that remembers member order.
+type jsonObject []jsonMember
+
+type jsonMember struct {
+	Key   string
+	Value interface{}
+}
+
+// MarshalJSON writes the members in their current order.
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(m.Key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(m.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// keySchema records the field order of one of our output structs and the
+// schemas of its nested struct (or slice-of-struct) fields.
+type keySchema struct {
+	order    map[string]int
+	children map[string]*keySchema
+}
+
+// rootSchema mirrors the Root struct tree.
+var rootSchema = schemaFor(reflect.TypeOf(Root{}))
+
+func schemaFor(t reflect.Type) *keySchema {
+	for t.Kind() == reflect.Slice || t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	s := &keySchema{order: map[string]int{}, children: map[string]*keySchema{}}
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		s.order[name] = i
+		if c := schemaFor(t.Field(i).Type); c != nil {
+			s.children[name] = c
+		}
+	}
+	return s
+}
+
+// sortJSONKeys re-encodes the indented JSON b so that every object's keys
+// are sorted alphabetically, except the fields of our own structs, which
+// keep their declared order (any keys they don't declare follow, sorted).
+func sortJSONKeys(b []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	v, err := readOrdered(dec)
+	if err != nil {
+		return nil, err
+	}
+	sortOrdered(v, rootSchema)
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// readOrdered decodes the next value from dec, keeping object member order.
+func readOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		var obj jsonObject
+		for dec.More() {
+			kt, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := kt.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected object key %v", kt)
+			}
+			val, err := readOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, jsonMember{key, val})
+		}
+		_, err := dec.Token() // '}'
+		return obj, err
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			val, err := readOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, val)
+		}
+		_, err := dec.Token() // ']'
+		return arr, err
+	}
+	return tok, nil
+}
+
+// sortOrdered sorts v's objects in place. schema is nil outside our structs.
+func sortOrdered(v interface{}, schema *keySchema) {
+	switch t := v.(type) {
+	case jsonObject:
+		sort.SliceStable(t, func(i, j int) bool {
+			a, b := t[i].Key, t[j].Key
+			if schema != nil {
+				ia, aKnown := schema.order[a]
+				ib, bKnown := schema.order[b]
+				if aKnown || bKnown {
+					if aKnown && bKnown {
+						return ia < ib
+					}
+					return aKnown
+				}
+			}
+			return a < b
+		})
+		for _, m := range t {
+			var child *keySchema
+			if schema != nil {
+				child = schema.children[m.Key]
+			}
+			sortOrdered(m.Value, child)
+		}
+	case []interface{}:
+		for _, e := range t {
+			sortOrdered(e, schema)
+		}
+	}
+}
+
+// explicitNotify re-encodes the indented JSON b with "notify": false added to
+// every news item that lacks it, in the NewsItem field position. omitempty
+// otherwise drops false, which some clients read as notify.
+func explicitNotify(b []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	v, err := readOrdered(dec)
+	if err != nil {
+		return nil, err
+	}
+	root, _ := v.(jsonObject)
+	schema := rootSchema.children["news"]
+	pos := schema.order["notify"]
+	for _, m := range root {
+		news, ok := m.Value.([]interface{})
+		if m.Key != "news" || !ok {
+			continue
+		}
+		for i, n := range news {
+			item, ok := n.(jsonObject)
+			if !ok {
+				continue
+			}
+			at, found := len(item), false
+			for j, f := range item {
+				if f.Key == "notify" {
+					found = true
+					break
+				}
+				if o, known := schema.order[f.Key]; known && o > pos && j < at {
+					at = j
+				}
+			}
+			if !found {
+				item = append(item[:at], append(jsonObject{{"notify", false}}, item[at:]...)...)
+				news[i] = item
+			}
+		}
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
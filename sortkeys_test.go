@@ -0,0 +1,43 @@
+package riperepo
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExplicitNotify(t *testing.T) {
+	src := []byte(`{"news": [
+		{"title": "Quiet", "identifier": "a", "notify": false, "url": "https://example.com/a"},
+		{"title": "Loud", "identifier": "b", "notify": true},
+		{"title": "Unset", "identifier": "c", "url": "https://example.com/c"}
+	]}`)
+	b, _, err := ProcessSource(context.Background(), src, Options{ExplicitNotify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	for _, want := range []string{
+		// false kept, in its field position: after imageURL, before url
+		`"identifier": "a",
+      "notify": false,
+      "url": "https://example.com/a"`,
+		`"identifier": "b",
+      "notify": true`,
+		`"identifier": "c",
+      "notify": false,
+      "url": "https://example.com/c"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks\n%s\nin\n%s", want, out)
+		}
+	}
+
+	b, _, err = ProcessSource(context.Background(), src, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), `"notify": false`) {
+		t.Errorf("notify: false written without ExplicitNotify:\n%s", b)
+	}
+}
@@ -0,0 +1,106 @@
+package riperepo
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// strictAliases are input keys the decoder understands but our structs don't
+// declare: older spellings it converts and fields it drops on purpose.
+var strictAliases = func() map[*keySchema][]string {
+	app := rootSchema.children["apps"]
+	version := app.children["versions"]
+	return map[*keySchema][]string{
+		app: {"screenshots", "previewVideos", "marketplaceID", "patreon", "buildVersion",
+			// a legacy single version written on the app itself
+			"version", "date", "versionDate", "versionDescription", "downloadURL", "size",
+			"minOSVersion", "maxOSVersion", "sha256"},
+		version: {"versionDate", "buildVersion", "patreon"},
+	}
+}()
+
+// checkStrict re-reads the JSON input b and returns an error naming every
+// object key, by path, that the decoder would ignore, e.g.
+// apps[0].versions[1].downlaodURL. Free-form values such as appPermissions
+// and localized maps aren't checked. NDJSON input is checked line by line.
+// Syntax errors are left to the real parse.
+func checkStrict(b []byte, ndjson bool) error {
+	var unknown []string
+	walkSchema(b, ndjson, func(path string, _ interface{}, known bool) {
+		if !known {
+			unknown = append(unknown, path)
+		}
+	})
+	if len(unknown) > 0 {
+		return fmt.Errorf("-strict: %d unknown field(s): %s", len(unknown), strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// walkSchema re-reads the JSON input b, a source or an array of sources, and
+// calls visit with the path, value and whether rootSchema (or strictAliases)
+// knows it for every object key, in sorted order within each object. It
+// descends into known fields that are structs or slices of structs, so
+// free-form values such as appPermissions and localized maps aren't visited.
+// NDJSON input is read line by line, with paths prefixed "line N: ".
+// Documents that don't parse are skipped; the real parse reports them.
+func walkSchema(b []byte, ndjson bool, visit func(path string, v interface{}, known bool)) {
+	docs := [][]byte{b}
+	if ndjson {
+		docs = bytes.Split(b, []byte("\n"))
+	}
+	for i, doc := range docs {
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		var v interface{}
+		if err := unmarshalNumbers(doc, &v); err != nil {
+			continue
+		}
+		prefix := ""
+		if ndjson {
+			prefix = fmt.Sprintf("line %d: ", i+1)
+		}
+		switch t := v.(type) {
+		case map[string]interface{}:
+			schemaWalk(t, rootSchema, prefix, visit)
+		case []interface{}:
+			// an array of sources
+			for j, elem := range t {
+				if m, ok := elem.(map[string]interface{}); ok {
+					schemaWalk(m, rootSchema, fmt.Sprintf("%s[%d].", prefix, j), visit)
+				}
+			}
+		}
+	}
+}
+
+func schemaWalk(m map[string]interface{}, schema *keySchema, path string, visit func(string, interface{}, bool)) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	aliases := stringSet(strictAliases[schema])
+	for _, k := range keys {
+		_, known := schema.order[k]
+		known = known || aliases[k]
+		visit(path+k, m[k], known)
+		child := schema.children[k]
+		if !known || child == nil {
+			continue
+		}
+		switch t := m[k].(type) {
+		case map[string]interface{}:
+			schemaWalk(t, child, path+k+".", visit)
+		case []interface{}:
+			for i, elem := range t {
+				if em, ok := elem.(map[string]interface{}); ok {
+					schemaWalk(em, child, fmt.Sprintf("%s%s[%d].", path, k, i), visit)
+				}
+			}
+		}
+	}
+}
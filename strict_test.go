@@ -0,0 +1,52 @@
+package riperepo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const schemaWalkSrc = `{
+	"name": "Repo", "colour": "red",
+	"apps": [{
+		"name": "A", "iconURL": null, "screenshots": [], "appPermissions": {"whatever": null},
+		"versions": [{"version": "1.0", "downlaodURL": "https://example.com/a.ipa", "size": null}]
+	}],
+	"news": [{"title": null, "extra": null}]
+}`
+
+func TestCheckStrict(t *testing.T) {
+	err := checkStrict([]byte(schemaWalkSrc), false)
+	if err == nil {
+		t.Fatal("checkStrict accepted unknown fields")
+	}
+	for _, p := range []string{"colour", "apps[0].versions[0].downlaodURL", "news[0].extra"} {
+		if !strings.Contains(err.Error(), p) {
+			t.Errorf("error %q doesn't name %s", err, p)
+		}
+	}
+	if strings.Contains(err.Error(), "whatever") || strings.Contains(err.Error(), "screenshots") {
+		t.Errorf("error %q names a free-form or aliased key", err)
+	}
+
+	ndjson := "{\"name\": \"A\"}\n\n{\"nmae\": \"B\"}\n"
+	if err := checkStrict([]byte(ndjson), true); err == nil || !strings.Contains(err.Error(), "line 3: nmae") {
+		t.Errorf("ndjson: err = %v, want line 3: nmae", err)
+	}
+	if err := checkStrict([]byte(`[{"name": "A"}, {"nmae": "B"}]`), false); err == nil || !strings.Contains(err.Error(), "[1].nmae") {
+		t.Errorf("array of sources: err = %v, want [1].nmae", err)
+	}
+}
+
+func TestReportNulls(t *testing.T) {
+	rep := &Report{}
+	n := reportNulls([]byte(schemaWalkSrc), false, rep)
+	want := []string{
+		"apps[0].iconURL is explicitly null",
+		"apps[0].versions[0].size is explicitly null",
+		"news[0].title is explicitly null",
+	}
+	if n != len(want) || !reflect.DeepEqual(rep.Warnings, want) {
+		t.Errorf("reportNulls = %d, %q; want %d, %q", n, rep.Warnings, len(want), want)
+	}
+}
@@ -0,0 +1,181 @@
+package riperepo
+
+import (
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// mapStrings applies fn to every string field, string slice element and
+// string map value reachable from v, which must be a pointer. Raw JSON such
+// as appPermissions is left alone. It returns how many strings fn changed.
+func mapStrings(v interface{}, fn func(string) string) int {
+	return mapStringsValue(reflect.ValueOf(v).Elem(), fn)
+}
+
+var rawMessageType = reflect.TypeOf(([]byte)(nil))
+
+func mapStringsValue(v reflect.Value, fn func(string) string) int {
+	n := 0
+	switch v.Kind() {
+	case reflect.String:
+		if s := fn(v.String()); s != v.String() {
+			v.SetString(s)
+			n++
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			n += mapStringsValue(v.Elem(), fn)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			n += mapStringsValue(v.Field(i), fn)
+		}
+	case reflect.Slice:
+		if v.Type().ConvertibleTo(rawMessageType) {
+			return 0
+		}
+		for i := 0; i < v.Len(); i++ {
+			n += mapStringsValue(v.Index(i), fn)
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			return 0
+		}
+		for _, k := range v.MapKeys() {
+			s := v.MapIndex(k).String()
+			if t := fn(s); t != s {
+				v.SetMapIndex(k, reflect.ValueOf(t))
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// stripControl removes C0 control characters other than newline and tab,
+// which some clients' JSON parsers choke on and others render as garbage.
+func stripControl(s string) string {
+	if strings.IndexFunc(s, isStrippedControl) < 0 {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if isStrippedControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func isStrippedControl(r rune) bool {
+	return r < 0x20 && r != '\n' && r != '\t'
+}
+
+// asciiPunct maps typographic punctuation to plain ASCII for -ascii-punct:
+//
+//	‘ ’ ‚ ‛ ′      '
+//	“ ” „ ‟ ″      "
+//	‐ ‑ ‒ – −      -
+//	—  ―           --
+//	…              ...
+//	no-break space  space
+var asciiPunct = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "‛", "'", "′", "'",
+	"“", `"`, "”", `"`, "„", `"`, "‟", `"`, "″", `"`,
+	"‐", "-", "‑", "-", "‒", "-", "–", "-", "−", "-",
+	"—", "--", "―", "--",
+	"…", "...",
+	"\u00a0", " ",
+)
+
+// mapTextFields applies fn to the human-readable text of a source (names,
+// subtitles, descriptions, captions and titles) but not to URLs,
+// identifiers or dates. It returns how many strings fn changed.
+func mapTextFields(out *Root, fn func(string) string) int {
+	n := 0
+	apply := func(s *string) {
+		if t := fn(*s); t != *s {
+			*s = t
+			n++
+		}
+	}
+	applyMap := func(m map[string]string) {
+		for k, s := range m {
+			if t := fn(s); t != s {
+				m[k] = t
+				n++
+			}
+		}
+	}
+	apply(&out.Name)
+	apply(&out.Subtitle)
+	apply(&out.Description)
+	for i := range out.Apps {
+		app := &out.Apps[i]
+		apply(&app.Name)
+		apply(&app.DeveloperName)
+		apply(&app.Subtitle)
+		apply(&app.LocalizedDescription)
+		applyMap(app.LocalizedNames)
+		applyMap(app.LocalizedSubtitles)
+		applyMap(app.LocalizedDescriptions)
+		for j := range app.Versions {
+			apply(&app.Versions[j].LocalizedDescription)
+		}
+	}
+	for i := range out.News {
+		apply(&out.News[i].Title)
+		apply(&out.News[i].Caption)
+	}
+	return n
+}
+
+// titleParticles stay lowercase in titleCaseName unless they start the name.
+var titleParticles = map[string]bool{
+	"a": true, "an": true, "and": true, "at": true, "by": true, "de": true, "for": true,
+	"in": true, "of": true, "on": true, "the": true, "to": true, "van": true, "von": true,
+}
+
+// titleCaseName title-cases a name written entirely in upper or lower case,
+// e.g. "JOHN DOE" or "john doe" to "John Doe"; mixed-case names are taken as
+// intentional branding and returned as-is. Particles such as "and" and "of"
+// are lowercased except as the first word, each hyphenated part is
+// capitalized, and in all-caps input words without vowels ("LLC", "TV") are
+// taken as acronyms and kept.
+func titleCaseName(s string) string {
+	upper, lower := strings.ToUpper(s), strings.ToLower(s)
+	if s != upper && s != lower || upper == lower {
+		return s
+	}
+	allCaps := s == upper
+	words := strings.Split(s, " ")
+	first := true
+	for i, w := range words {
+		if w == "" {
+			continue
+		}
+		lw := strings.ToLower(w)
+		switch {
+		case allCaps && len(w) > 1 && !strings.ContainsAny(lw, "aeiouy"):
+			// acronym, keep
+		case !first && titleParticles[lw]:
+			words[i] = lw
+		default:
+			parts := strings.Split(lw, "-")
+			for j, p := range parts {
+				parts[j] = capitalize(p)
+			}
+			words[i] = strings.Join(parts, "-")
+		}
+		first = false
+	}
+	return strings.Join(words, " ")
+}
+
+// capitalize upper-cases the first letter of s.
+func capitalize(s string) string {
+	for i, r := range s {
+		return s[:i] + strings.ToUpper(string(r)) + s[i+utf8.RuneLen(r):]
+	}
+	return s
+}
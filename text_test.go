@@ -0,0 +1,108 @@
+package riperepo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStripControl(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"nul\x00here", "nulhere"},
+		{"page\fbreak", "pagebreak"},
+		{"vtab\vand bell\a", "vtaband bell"},
+		{"line one\nline two\ttabbed", "line one\nline two\ttabbed"},
+		{"crlf\r\n", "crlf\n"},
+		{"ünïcödé ✓ and nbsp", "ünïcödé ✓ and nbsp"},
+		{"\x00\x01\x1f", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := stripControl(tt.in); got != tt.want {
+			t.Errorf("stripControl(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStripControlOption(t *testing.T) {
+	src := `{"name": "Repo\u0000", "apps": [{"name": "A\f", "bundleIdentifier": "com.a",
+		"versions": [{"version": "1", "localizedDescription": "Fixes\u000c\nMore"}]}]}`
+	out, _, err := Build(context.Background(), []byte(src), Options{StripControl: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "Repo" || out.Apps[0].Name != "A" || out.Apps[0].Versions[0].LocalizedDescription != "Fixes\nMore" {
+		t.Errorf("got %q, %q, %q", out.Name, out.Apps[0].Name, out.Apps[0].Versions[0].LocalizedDescription)
+	}
+
+	out, _, err = Build(context.Background(), []byte(src), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Apps[0].Name != "A\f" {
+		t.Errorf("without StripControl name = %q, want it untouched", out.Apps[0].Name)
+	}
+}
+
+func TestASCIIPunct(t *testing.T) {
+	// one row per line of the table in asciiPunct's doc comment
+	tests := []struct{ in, want string }{
+		{"‘single’ ‚low‛ 5′", "'single' 'low' 5'"},
+		{"“double” „low‟ 12″", `"double" "low" 12"`},
+		{"a‐b a‑b 1‒2 1–2 −3", "a-b a-b 1-2 1-2 -3"},
+		{"wait—what ―", "wait--what --"},
+		{"and so on…", "and so on..."},
+		{"no break", "no break"},
+		{"plain 'ASCII' \"text\" - ...", "plain 'ASCII' \"text\" - ..."},
+		{"café 😀 ✓", "café 😀 ✓"},
+	}
+	for _, tt := range tests {
+		if got := asciiPunct.Replace(tt.in); got != tt.want {
+			t.Errorf("asciiPunct(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestASCIIPunctTextFieldsOnly(t *testing.T) {
+	src := `{"apps": [{"name": "It’s “Great”", "bundleIdentifier": "com.a",
+		"iconURL": "https://example.com/it’s.png"}]}`
+	out, _, err := Build(context.Background(), []byte(src), Options{ASCIIPunct: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.Apps[0].Name; got != `It's "Great"` {
+		t.Errorf("name = %q", got)
+	}
+	if got := out.Apps[0].IconURL; got != "https://example.com/it’s.png" {
+		t.Errorf("iconURL = %q, want URLs left alone", got)
+	}
+}
+
+func TestTitleCaseName(t *testing.T) {
+	tests := []struct{ in, want string }{
+		// all caps
+		{"JOHN DOE", "John Doe"},
+		{"ACME SOFTWARE LLC", "Acme Software LLC"},
+		{"HOUSE OF THE DRAGON", "House of the Dragon"},
+		{"THE BEST TV APPS", "The Best TV Apps"},
+		{"JEAN-LUC PICARD", "Jean-Luc Picard"},
+		// lowercase
+		{"john doe", "John Doe"},
+		{"the app factory", "The App Factory"},
+		{"ludwig van beethoven", "Ludwig van Beethoven"},
+		{"llc studio", "Llc Studio"}, // acronyms are only recognized in all caps
+		{"émile zola", "Émile Zola"},
+		// mixed case is taken as intentional
+		{"iOS Dev Team", "iOS Dev Team"},
+		{"McDonald Apps", "McDonald Apps"},
+		{"riley Testut", "riley Testut"},
+		// nothing to case
+		{"123 456", "123 456"},
+		{"", ""},
+		{"  SPACED  OUT ", "  Spaced  Out "},
+	}
+	for _, tt := range tests {
+		if got := titleCaseName(tt.in); got != tt.want {
+			t.Errorf("titleCaseName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package riperepo
+
+// AppTransformer rewrites an app after the built-in passes, for per-repo
+// tweaks (renaming developers, injecting tint colors) that don't belong in
+// the core. Transform receives a copy and returns the app to publish.
+type AppTransformer interface {
+	Transform(App) App
+}
+
+// AppTransformerFunc adapts a plain function to AppTransformer.
+type AppTransformerFunc func(App) App
+
+// Transform calls f(app).
+func (f AppTransformerFunc) Transform(app App) App {
+	return f(app)
+}
+
+// transformApps runs each transformer over every app, in order.
+func transformApps(apps []App, transformers []AppTransformer) {
+	for i := range apps {
+		for _, t := range transformers {
+			apps[i] = t.Transform(apps[i])
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package riperepo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseDotted splits a dotted numeric version like "14.2.1" into its parts.
+// ok is false if any part isn't a non-negative integer.
+func parseDotted(s string) (parts []int, ok bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, false
+	}
+	for _, p := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, true
+}
+
+// compareDotted compares two parsed dotted versions, treating missing
+// trailing parts as zero, so 14 == 14.0. It returns -1, 0 or 1.
+func compareDotted(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// checkOSVersions warns when a version's minOSVersion or maxOSVersion isn't a
+// dotted number, or when the range they describe is empty.
+func checkOSVersions(apps []App, rep *Report) {
+	for _, app := range apps {
+		for _, v := range app.Versions {
+			min, minOK := parseDotted(v.MinOSVersion)
+			max, maxOK := parseDotted(v.MaxOSVersion)
+			if v.MinOSVersion != "" && !minOK {
+				rep.Warnf("app %q version %q: minOSVersion %q is not a dotted version number", app.Name, v.Version, v.MinOSVersion)
+			}
+			if v.MaxOSVersion != "" && !maxOK {
+				rep.Warnf("app %q version %q: maxOSVersion %q is not a dotted version number", app.Name, v.Version, v.MaxOSVersion)
+			}
+			if minOK && maxOK && compareDotted(min, max) > 0 {
+				rep.Warnf("app %q version %q: minOSVersion %s is above maxOSVersion %s", app.Name, v.Version, v.MinOSVersion, v.MaxOSVersion)
+			}
+		}
+	}
+}
+
+// trimVersionPrefix strips a leading "v" or "V" from a version string when
+// what follows is version-like (see isVersionLike), so "v1.2" and
+// "v1.2.0-beta.1" lose it while "v-next", "v1abc", "v2-final" and "V3 beta"
+// are left alone.
+func trimVersionPrefix(s string) string {
+	if len(s) > 1 && (s[0] == 'v' || s[0] == 'V') && isVersionLike(s[1:]) {
+		return s[1:]
+	}
+	return s
+}
+
+// isVersionLike reports whether s is a dotted number such as "1.2", or a
+// semver version with a "-prerelease" or "+build" suffix, which semver only
+// allows after a full MAJOR.MINOR.PATCH core.
+func isVersionLike(s string) bool {
+	core, suffix := s, ""
+	i := strings.IndexAny(s, "-+")
+	if i >= 0 {
+		core, suffix = s[:i], s[i+1:]
+	}
+	parts, ok := parseDotted(core)
+	if !ok || strings.Trim(core, "0123456789.") != "" {
+		return false
+	}
+	if i < 0 {
+		return true
+	}
+	if len(parts) != 3 || suffix == "" {
+		return false
+	}
+	for _, r := range suffix {
+		if !('0' <= r && r <= '9' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || r == '.' || r == '-' || r == '+') {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeVersionStrings applies trimVersionPrefix to every version.
+func normalizeVersionStrings(apps []App) {
+	for i := range apps {
+		for j := range apps[i].Versions {
+			v := &apps[i].Versions[j]
+			if t := trimVersionPrefix(v.Version); t != v.Version {
+				logv.Debugf("app %q: version %q normalized to %q", apps[i].Name, v.Version, t)
+				v.Version = t
+			}
+		}
+	}
+}
+
+// compareSemver compares two version strings semver-style: dotted numeric
+// cores (after an optional leading v), then a release ranks above any
+// "-prerelease" of the same core, and prereleases compare as strings. ok is
+// false when either core isn't numeric.
+func compareSemver(a, b string) (cmp int, ok bool) {
+	coreA, preA := splitPrerelease(a)
+	coreB, preB := splitPrerelease(b)
+	pa, okA := parseDotted(trimVersionPrefix(coreA))
+	pb, okB := parseDotted(trimVersionPrefix(coreB))
+	if !okA || !okB {
+		return 0, false
+	}
+	if c := compareDotted(pa, pb); c != 0 {
+		return c, true
+	}
+	switch {
+	case preA == preB:
+		return 0, true
+	case preA == "":
+		return 1, true
+	case preB == "":
+		return -1, true
+	case preA < preB:
+		return -1, true
+	}
+	return 1, true
+}
+
+// splitPrerelease splits "1.2.0-beta.1+build" into "1.2.0" and "beta.1",
+// dropping build metadata.
+func splitPrerelease(s string) (core, pre string) {
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
+}
+
+// checkVersionOrder warns when an app's versions aren't listed newest first:
+// clients take the first entry as the latest, so an older version above a
+// newer one hides the update. Versions that don't parse are skipped.
+func checkVersionOrder(apps []App, rep *Report) {
+	for _, app := range apps {
+		for i := 0; i+1 < len(app.Versions); i++ {
+			cur, next := app.Versions[i].Version, app.Versions[i+1].Version
+			if c, ok := compareSemver(cur, next); ok && c < 0 {
+				rep.Warnf("app %q: version %s is listed above newer version %s", app.Name, cur, next)
+			}
+		}
+	}
+}
+
+// checkStale warns about apps whose latest version is older than maxAge at
+// now. Apps whose latest date doesn't parse can't be judged and get their
+// own warning.
+func checkStale(apps []App, maxAge time.Duration, now time.Time, rep *Report) {
+	for _, app := range apps {
+		latest, ok := app.LatestVersion()
+		if !ok {
+			continue
+		}
+		t, ok := latest.ParsedDate()
+		if !ok {
+			rep.Warnf("app %q: latest version %s has no parseable date (%q); can't check staleness", app.Name, latest.Version, latest.Date)
+			continue
+		}
+		if age := now.Sub(t); age > maxAge {
+			rep.StaleApps++
+			rep.Warnf("app %q: latest version %s is from %s, %d days ago", app.Name, latest.Version, t.Format("2006-01-02"), int(age.Hours()/24))
+		}
+	}
+}
+
+// filterMinOS drops versions whose minOSVersion is above max or below min,
+// either of which may be nil to leave that side open. Versions without a
+// minOSVersion, or with one that isn't a dotted number (checkOSVersions
+// warns about those), are kept. It returns how many versions were dropped
+// and how many were kept unchecked because minOSVersion didn't parse.
+func filterMinOS(apps []App, min, max []int) (dropped, unparsed int) {
+	for i := range apps {
+		app := &apps[i]
+		kept := app.Versions[:0]
+		for _, v := range app.Versions {
+			minOS, ok := parseDotted(v.MinOSVersion)
+			switch {
+			case v.MinOSVersion == "":
+			case !ok:
+				logv.Debugf("app %q version %q: minOSVersion %q unparseable, kept", app.Name, v.Version, v.MinOSVersion)
+				unparsed++
+			case max != nil && compareDotted(minOS, max) > 0, min != nil && compareDotted(minOS, min) < 0:
+				logv.Debugf("app %q version %q: minOSVersion %s out of range, dropped", app.Name, v.Version, v.MinOSVersion)
+				dropped++
+				continue
+			}
+			kept = append(kept, v)
+		}
+		app.Versions = kept
+	}
+	return dropped, unparsed
+}
+
+// parseOSBound parses the value of the OS version flag name; "" gives nil,
+// an open bound.
+func parseOSBound(name, value string) ([]int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	v, ok := parseDotted(value)
+	if !ok {
+		return nil, fmt.Errorf("-%s %q is not a dotted version number", name, value)
+	}
+	return v, nil
+}
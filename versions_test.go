@@ -0,0 +1,181 @@
+package riperepo
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOSVersionsRoundTrip(t *testing.T) {
+	src := `{"apps": [{"name": "A", "bundleIdentifier": "com.a", "versions": [
+		{"version": "1.0", "downloadURL": "https://example.com/a.ipa", "minOSVersion": "12.0", "maxOSVersion": "16.7.2"}]}]}`
+	b, rep, err := ProcessSource(context.Background(), []byte(src), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Decode(b, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := out.Apps[0].Versions[0]
+	if v.MinOSVersion != "12.0" || v.MaxOSVersion != "16.7.2" {
+		t.Errorf("min, max = %q, %q; want 12.0, 16.7.2", v.MinOSVersion, v.MaxOSVersion)
+	}
+	for _, w := range rep.Warnings {
+		if strings.Contains(w, "OSVersion") {
+			t.Errorf("unexpected warning: %s", w)
+		}
+	}
+}
+
+func TestCheckOSVersions(t *testing.T) {
+	apps := []App{{Name: "A", Versions: []Version{
+		{Version: "1", MinOSVersion: "15.0", MaxOSVersion: "14.8"},
+		{Version: "2", MaxOSVersion: "latest"},
+	}}}
+	rep := &Report{}
+	checkOSVersions(apps, rep)
+	if len(rep.Warnings) != 2 ||
+		!strings.Contains(rep.Warnings[0], "minOSVersion 15.0 is above maxOSVersion 14.8") ||
+		!strings.Contains(rep.Warnings[1], `maxOSVersion "latest" is not a dotted version number`) {
+		t.Errorf("warnings = %q", rep.Warnings)
+	}
+}
+
+func TestTrimVersionPrefix(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"v1.0", "1.0"},
+		{"V2.3.4", "2.3.4"},
+		{"1.0", "1.0"},
+		{"v", "v"},
+		{"v-next", "v-next"},
+		{"version2", "version2"},
+		{"vv1", "vv1"},
+		{"", ""},
+		{"v1.2.0-beta.1", "1.2.0-beta.1"},
+		{"v1.2.0+build.7", "1.2.0+build.7"},
+		{"v1abc", "v1abc"},
+		{"v2-final", "v2-final"},
+		{"V3 beta", "V3 beta"},
+		{"v1.2.", "v1.2."},
+		{"v+1", "v+1"},
+		{"v 1", "v 1"},
+		{"v1.2.0-", "v1.2.0-"},
+		{"v1.2.0-rc 1", "v1.2.0-rc 1"},
+	}
+	for _, tt := range tests {
+		if got := trimVersionPrefix(tt.in); got != tt.want {
+			t.Errorf("trimVersionPrefix(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCompareDotted(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"14", "14.0", 0},
+		{"14.0.0", "14", 0},
+		{"14.1", "14.0.9", 1},
+		{"12.10", "12.5", 1}, // numeric, not lexical
+		{"9", "10", -1},
+		{"16.7.2", "17", -1},
+		{"0", "0.0.1", -1},
+	}
+	for _, tt := range tests {
+		a, okA := parseDotted(tt.a)
+		b, okB := parseDotted(tt.b)
+		if !okA || !okB {
+			t.Fatalf("parseDotted(%q or %q) failed", tt.a, tt.b)
+		}
+		if got := compareDotted(a, b); got != tt.want {
+			t.Errorf("compareDotted(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := compareDotted(b, a); got != -tt.want {
+			t.Errorf("compareDotted(%s, %s) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+
+	for _, s := range []string{"", " ", "14.", ".14", "14..0", "14.x", "iOS 14", "-1", "14.0-beta", "v14"} {
+		if parts, ok := parseDotted(s); ok {
+			t.Errorf("parseDotted(%q) = %v, want failure", s, parts)
+		}
+	}
+}
+
+func TestFilterMinOS(t *testing.T) {
+	mk := func() []App {
+		var vs []Version
+		for _, os := range []string{"12", "12.0.0", "12.5", "12.10", "13", "11.4.1", "", "iOS 14", "14.x"} {
+			vs = append(vs, Version{Version: "v" + os, MinOSVersion: os})
+		}
+		return []App{{Name: "A", Versions: vs}}
+	}
+	kept := func(apps []App) []string {
+		var os []string
+		for _, v := range apps[0].Versions {
+			os = append(os, v.MinOSVersion)
+		}
+		return os
+	}
+	bound := func(s string) []int {
+		v, err := parseOSBound("test", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name              string
+		min, max          []int
+		want              []string
+		dropped, unparsed int
+	}{
+		{"max 12.5", nil, bound("12.5"), []string{"12", "12.0.0", "12.5", "11.4.1", "", "iOS 14", "14.x"}, 2, 2},
+		{"max 12 keeps 12.0.0", nil, bound("12"), []string{"12", "12.0.0", "11.4.1", "", "iOS 14", "14.x"}, 3, 2},
+		{"min 12.5", bound("12.5"), nil, []string{"12.5", "12.10", "13", "", "iOS 14", "14.x"}, 3, 2},
+		{"range", bound("12.0"), bound("12.9"), []string{"12", "12.0.0", "12.5", "", "iOS 14", "14.x"}, 3, 2},
+		{"open", nil, nil, []string{"12", "12.0.0", "12.5", "12.10", "13", "11.4.1", "", "iOS 14", "14.x"}, 0, 2},
+	}
+	for _, tt := range tests {
+		apps := mk()
+		dropped, unparsed := filterMinOS(apps, tt.min, tt.max)
+		if got := kept(apps); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: kept %q, want %q", tt.name, got, tt.want)
+		}
+		if dropped != tt.dropped || unparsed != tt.unparsed {
+			t.Errorf("%s: dropped, unparsed = %d, %d; want %d, %d", tt.name, dropped, unparsed, tt.dropped, tt.unparsed)
+		}
+	}
+
+	if _, err := parseOSBound("max-minos", "12.x"); err == nil {
+		t.Error("parseOSBound accepted 12.x")
+	}
+	if v, err := parseOSBound("max-minos", ""); v != nil || err != nil {
+		t.Errorf("parseOSBound(\"\") = %v, %v; want an open bound", v, err)
+	}
+}
+
+func TestCompareSemver(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+		ok   bool
+	}{
+		{"v1.2", "1.2.0", 0, true},
+		{"v1.2-beta", "1.2", -1, true},
+		{"1.10", "v1.9", 1, true},
+		{"1.0-alpha", "1.0-beta", -1, true},
+		{"v1abc", "1.0", 0, false},
+		{"latest", "1.0", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := compareSemver(tt.a, tt.b)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("compareSemver(%q, %q) = %d, %v; want %d, %v", tt.a, tt.b, got, ok, tt.want, tt.ok)
+		}
+	}
+}